@@ -0,0 +1,107 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AggregateFunc is an aggregate function of Operator.Aggregate.
+type AggregateFunc string
+
+const (
+	AggregateCount = AggregateFunc("COUNT")
+	AggregateSum   = AggregateFunc("SUM")
+	AggregateAvg   = AggregateFunc("AVG")
+	AggregateMin   = AggregateFunc("MIN")
+	AggregateMax   = AggregateFunc("MAX")
+)
+
+func (fn AggregateFunc) valid() bool {
+	switch fn {
+	case AggregateCount, AggregateSum, AggregateAvg, AggregateMin, AggregateMax:
+		return true
+	}
+	return false
+}
+
+// SqlAggregate renders `SELECT fn(column) FROM table WHERE condition`, column `*` is allowed.
+func (op *Operator) SqlAggregate(fn AggregateFunc, column string, condition string) string {
+	if !fn.valid() {
+		panic(fmt.Errorf("sqlx: unknown aggregate function `%s`", fn))
+	}
+	if len(condition) < 1 {
+		panic(ErrEmptyCondition)
+	}
+
+	var buf strings.Builder
+	buf.WriteString("SELECT ")
+	buf.WriteString(string(fn))
+	buf.WriteByte('(')
+	buf.WriteString(column)
+	buf.WriteString(") FROM ")
+	buf.WriteString(op.model.TableName())
+	buf.WriteString(" WHERE ")
+	buf.WriteString(condition)
+	return buf.String()
+}
+
+// Aggregate scans the result of the aggregate function to dist, like `*int64` or `*sql.NullFloat64`.
+func (op *Operator) Aggregate(
+	ctx context.Context, fn AggregateFunc, column string, condition string,
+	params interface{},
+	dist interface{},
+) error {
+	if !fn.valid() {
+		return fmt.Errorf("sqlx: unknown aggregate function `%s`", fn)
+	}
+	if column != "*" {
+		if _, ok := op.smap.Names[column]; !ok {
+			return fmt.Errorf("sqlx: unknown column `%s`", column)
+		}
+	}
+	ctx, exe := PickExecutor(ctx)
+	return exe.GetDirect(ctx, op.SqlAggregate(fn, column, op.scope(ctx, condition)), params, DirectDists{dist})
+}
+
+func (op *Operator) Count(ctx context.Context, condition string, params interface{}) (int64, error) {
+	var v int64
+	err := op.Aggregate(ctx, AggregateCount, "*", condition, params, &v)
+	return v, err
+}
+
+// Sum scans the sum of the column to dist, the sum of no rows is NULL.
+func (op *Operator) Sum(ctx context.Context, column string, condition string, params interface{}, dist interface{}) error {
+	return op.Aggregate(ctx, AggregateSum, column, condition, params, dist)
+}
+
+// Min scans the min value of the column to dist, the min value of no rows is NULL.
+func (op *Operator) Min(ctx context.Context, column string, condition string, params interface{}, dist interface{}) error {
+	return op.Aggregate(ctx, AggregateMin, column, condition, params, dist)
+}
+
+// Max scans the max value of the column to dist, the max value of no rows is NULL.
+func (op *Operator) Max(ctx context.Context, column string, condition string, params interface{}, dist interface{}) error {
+	return op.Aggregate(ctx, AggregateMax, column, condition, params, dist)
+}
+
+func (op *Operator) SqlExists(condition string) string {
+	if len(condition) < 1 {
+		panic(ErrEmptyCondition)
+	}
+
+	var buf strings.Builder
+	buf.WriteString("SELECT EXISTS(SELECT 1 FROM ")
+	buf.WriteString(op.model.TableName())
+	buf.WriteString(" WHERE ")
+	buf.WriteString(condition)
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func (op *Operator) Exists(ctx context.Context, condition string, params interface{}) (bool, error) {
+	var v bool
+	ctx, exe := PickExecutor(ctx)
+	err := exe.GetDirect(ctx, op.SqlExists(op.scope(ctx, condition)), params, DirectDists{&v})
+	return v, err
+}
@@ -0,0 +1,62 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+type testScore struct {
+	ID    int64 `db:"id"`
+	Score int64 `db:"score"`
+}
+
+func (s *testScore) TableName() string { return "score" }
+
+func (s *testScore) TableColumns() []string { return nil }
+
+func TestOperator_Aggregate(t *testing.T) {
+	db := openTestSqlite(t)
+	useTestSqlite(t, db)
+	ctx := context.Background()
+	if _, err := db.Execute(ctx, "CREATE TABLE score (id INTEGER PRIMARY KEY, score INTEGER)", nil); err != nil {
+		t.Fatal(err)
+	}
+	op := NewOperator(&testScore{})
+
+	// no rows, SUM and MAX are NULL
+	count, err := op.Count(ctx, "1=1", nil)
+	if err != nil || count != 0 {
+		t.Errorf("unexpected count: %d %v", count, err)
+	}
+	var sum, max sql.NullInt64
+	if err = op.Sum(ctx, "score", "1=1", nil, &sum); err != nil || sum.Valid {
+		t.Errorf("unexpected sum: %v %v", sum, err)
+	}
+	if err = op.Max(ctx, "score", "1=1", nil, &max); err != nil || max.Valid {
+		t.Errorf("unexpected max: %v %v", max, err)
+	}
+
+	for i, score := range []int64{3, 5, 4} {
+		if _, err = db.Execute(ctx, "INSERT INTO score (id, score) VALUES (${id}, ${score})", Params{"id": i + 1, "score": score}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count, err = op.Count(ctx, "score>${score}", Params{"score": 3}); err != nil || count != 2 {
+		t.Errorf("unexpected count: %d %v", count, err)
+	}
+	if err = op.Sum(ctx, "score", "1=1", nil, &sum); err != nil || sum.Int64 != 12 {
+		t.Errorf("unexpected sum: %v %v", sum, err)
+	}
+	if err = op.Max(ctx, "score", "1=1", nil, &max); err != nil || max.Int64 != 5 {
+		t.Errorf("unexpected max: %v %v", max, err)
+	}
+	var avg float64
+	if err = op.Aggregate(ctx, AggregateAvg, "score", "1=1", nil, &avg); err != nil || avg != 4 {
+		t.Errorf("unexpected avg: %v %v", avg, err)
+	}
+
+	if err = op.Aggregate(ctx, AggregateFunc("COUNT(*) FROM score; --"), "score", "1=1", nil, &avg); err == nil {
+		t.Errorf("unknown aggregate function is accepted")
+	}
+}
@@ -1,7 +1,13 @@
 package sqlx
 
 import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 type _Param struct {
@@ -10,93 +16,360 @@ type _Param struct {
 	end   int
 }
 
-func scanParams(q []rune) []_Param {
-	var lst []_Param
-	begin := -1
-	status := -1
-	escape := false
-	var quote rune
-	var buf []rune
-	for idx, r := range q {
-		if escape {
-			escape = false
+// skipQuoted returns the index after the closing quote of the quoted text starting at `begin`.
+// a doubled quote is an escaped quote, and backslash escapes the next rune if `backslash` is true.
+func skipQuoted(q []rune, begin int, quote rune, backslash bool) int {
+	for i := begin + 1; i < len(q); i++ {
+		r := q[i]
+		if backslash && r == '\\' {
+			i++
 			continue
 		}
-		if r == '\\' {
-			escape = true
-			continue
+		if r == quote {
+			if i+1 < len(q) && q[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(q)
+}
+
+// skipLine returns the index after the end of the line comment starting at `begin`.
+func skipLine(q []rune, begin int) int {
+	for i := begin; i < len(q); i++ {
+		if q[i] == '\n' {
+			return i + 1
 		}
+	}
+	return len(q)
+}
 
-		if quote != 0 {
-			if r == quote {
-				quote = 0
+// skipBlock returns the index after the end of the block comment starting at `begin`.
+func skipBlock(q []rune, begin int, nested bool) int {
+	depth := 0
+	for i := begin; i+1 < len(q); i++ {
+		if q[i] == '/' && q[i+1] == '*' {
+			if depth == 0 || nested {
+				depth++
 			}
+			i++
 			continue
 		}
+		if q[i] == '*' && q[i+1] == '/' {
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(q)
+}
 
-		if r == '"' || r == '\'' {
-			quote = r
-			continue
+func isIdentRune(r rune, first bool) bool {
+	if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r > 127 {
+		return true
+	}
+	return !first && r >= '0' && r <= '9'
+}
+
+// dollarTag returns the end index of the postgres dollar quote tag (like `$body$`) starting at `begin`, or -1.
+func dollarTag(q []rune, begin int) int {
+	for i := begin + 1; i < len(q); i++ {
+		if q[i] == '$' {
+			return i + 1
+		}
+		if !isIdentRune(q[i], i == begin+1) {
+			return -1
 		}
+	}
+	return -1
+}
 
-		if status < 0 && r == '$' {
-			status++
-			begin = idx
+// skipDollarQuoted returns the index after the closing tag of the postgres dollar quoted text.
+func skipDollarQuoted(q []rune, begin int, tagEnd int) int {
+	tag := q[begin:tagEnd]
+	for i := tagEnd; i+len(tag) <= len(q); i++ {
+		if q[i] != '$' {
 			continue
 		}
+		matched := true
+		for j, r := range tag {
+			if q[i+j] != r {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i + len(tag)
+		}
+	}
+	return len(q)
+}
+
+// isEscapeString reports whether the quote at `idx` begins a postgres escape string, like `E'\n'`.
+func isEscapeString(q []rune, idx int) bool {
+	if idx < 1 || (q[idx-1] != 'E' && q[idx-1] != 'e') {
+		return false
+	}
+	return idx < 2 || !isIdentRune(q[idx-2], false)
+}
+
+// scanParams finds the `${name}` params, skipping the string literals, quoted identifiers and comments of the driver.
+func scanParams(d DriverType, q []rune) []_Param {
+	var lst []_Param
+	mysql := d == DriverTypeMysql
+	postgres := d == DriverTypePostgres
+	sqlite := d == DriverTypeSqlite3
+
+	i := 0
+	for i < len(q) {
+		r := q[i]
+		var next rune
+		if i+1 < len(q) {
+			next = q[i+1]
+		}
 
-		switch status {
-		case 0:
-			if r == '{' {
-				status++
-			} else {
-				status = -1
+		switch {
+		case r == '\'':
+			i = skipQuoted(q, i, r, mysql || (postgres && isEscapeString(q, i)))
+		case r == '"':
+			i = skipQuoted(q, i, r, mysql)
+		case r == '`' && (mysql || sqlite):
+			i = skipQuoted(q, i, r, false)
+		case r == '[' && sqlite:
+			i = skipQuoted(q, i, ']', false)
+		case r == '-' && next == '-':
+			if mysql && i+2 < len(q) && !unicode.IsSpace(q[i+2]) { // mysql requires a whitespace after `--`
+				i++
+				continue
 			}
-			continue
-		case 1:
-			if r == '}' {
-				status = -1
-				lst = append(lst, _Param{name: string(buf), begin: begin, end: idx})
-				buf = buf[:0]
+			i = skipLine(q, i)
+		case r == '#' && mysql:
+			i = skipLine(q, i)
+		case r == '/' && next == '*':
+			i = skipBlock(q, i, postgres)
+		case r == '$' && next == '{':
+			end := -1
+			for j := i + 2; j < len(q); j++ {
+				if q[j] == '}' {
+					end = j
+					break
+				}
+			}
+			if end < 0 {
+				return lst
+			}
+			lst = append(lst, _Param{name: string(q[i+2 : end]), begin: i, end: end})
+			i = end + 1
+		case r == '$' && postgres:
+			if tagEnd := dollarTag(q, i); tagEnd > 0 {
+				i = skipDollarQuoted(q, i, tagEnd)
 			} else {
-				buf = append(buf, r)
+				i++
 			}
+		default:
+			i++
 		}
 	}
-
 	return lst
 }
 
-func BindParams(d DriverType, qs string) (string, []string) {
+// _Template is a parsed query, `parts[i]` is the raw sql before the i-th param, which refers to `keys[refs[i]]`.
+// `${1}` refers to the first element of a ParamSlice, and `${}` refers to the next one.
+// If the driver can reuse placeholders, repeated keys share one placeholder.
+type _Template struct {
+	driverType DriverType
+	parts      []string
+	keys       []string
+	refs       []int
+	query      string // rendered with one placeholder for each key
+}
+
+func parseTemplate(d DriverType, qs string) *_Template {
+	tpl := &_Template{driverType: d}
 	if !strings.Contains(qs, "${") {
-		return qs, nil
+		tpl.parts = []string{qs}
+		tpl.query = qs
+		return tpl
+	}
+
+	reuse := d.ReusablePlaceholder()
+	var indexes map[string]int
+	if reuse {
+		indexes = map[string]int{}
 	}
 
 	q := []rune(qs)
-	lst := scanParams(q)
-	if len(lst) < 1 {
+	lst := scanParams(d, q)
+	cur := 0
+	seq := 0
+	for _, param := range lst {
+		name := strings.TrimSpace(param.name)
+		if len(name) < 1 { // `${}` is the next positional param
+			seq++
+			name = strconv.Itoa(seq)
+		}
+		tpl.parts = append(tpl.parts, string(q[cur:param.begin]))
+		cur = param.end + 1
+
+		if reuse {
+			if ind, ok := indexes[name]; ok {
+				tpl.refs = append(tpl.refs, ind)
+				continue
+			}
+			indexes[name] = len(tpl.keys)
+		}
+		tpl.refs = append(tpl.refs, len(tpl.keys))
+		tpl.keys = append(tpl.keys, name)
+	}
+	tpl.parts = append(tpl.parts, string(q[cur:]))
+	tpl.query = tpl.render(nil)
+	return tpl
+}
+
+// render writes the driver placeholders. `sizes[i]` is the count of placeholders of `keys[i]`, nil means one for each.
+func (tpl *_Template) render(sizes []int) string {
+	if len(tpl.keys) < 1 {
+		return tpl.parts[0]
+	}
+	if sizes == nil && len(tpl.query) > 0 {
+		return tpl.query
+	}
+
+	var placeholder = tpl.driverType.PlaceholderFunc()
+
+	offsets := make([]int, len(tpl.keys))
+	offset := 0
+	for i := range tpl.keys {
+		offsets[i] = offset
+		if sizes != nil {
+			offset += sizes[i]
+		} else {
+			offset++
+		}
+	}
+
+	var buf strings.Builder
+	for i, ref := range tpl.refs {
+		buf.WriteString(tpl.parts[i])
+
+		size := 1
+		if sizes != nil {
+			size = sizes[ref]
+		}
+		for j := 0; j < size; j++ {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(placeholder(offsets[ref]+j, tpl.keys[ref]))
+		}
+	}
+	buf.WriteString(tpl.parts[len(tpl.parts)-1])
+	return buf.String()
+}
+
+func BindParams(d DriverType, qs string) (string, []string) {
+	tpl := parseTemplate(d, qs)
+	if len(tpl.keys) < 1 {
 		return qs, nil
 	}
+	return tpl.query, tpl.keys
+}
 
-	var placeholder = d.PlaceholderFunc()
+var ErrEmptySliceParam = errors.New("sqlx: empty slice param")
 
+func isExpandable(v interface{}) (reflect.Value, bool) {
+	if v == nil {
+		return reflect.Value{}, false
+	}
+	if _, ok := v.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return reflect.Value{}, false
+	}
+	return rv, true
+}
+
+// expandArgs flattens slice values and encodes their elements, the returned sizes is nil if no arg is expanded.
+func expandArgs(d DriverType, keys []string, args []interface{}) ([]interface{}, []int, error) {
+	expanded := false
+	for _, arg := range args {
+		if _, ok := isExpandable(arg); ok {
+			expanded = true
+			break
+		}
+	}
+	if !expanded {
+		return args, nil, nil
+	}
+
+	m := loadEncoders()
+	flat := make([]interface{}, 0, len(args))
+	sizes := make([]int, len(args))
+	for i, arg := range args {
+		rv, ok := isExpandable(arg)
+		if !ok {
+			flat = append(flat, arg)
+			sizes[i] = 1
+			continue
+		}
+		if rv.Len() < 1 {
+			return nil, nil, fmt.Errorf("%w: `%s`", ErrEmptySliceParam, keys[i])
+		}
+		for j := 0; j < rv.Len(); j++ {
+			v, err := encodeArg(m, d, rv.Index(j).Interface())
+			if err != nil {
+				return nil, nil, err
+			}
+			flat = append(flat, v)
+		}
+		sizes[i] = rv.Len()
+	}
+	return flat, sizes, nil
+}
+
+func sizesSignature(sizes []int) string {
 	var buf strings.Builder
-	var keys []string
-	cur := 0
-	for idx, param := range lst {
-		for cur < param.begin {
-			buf.WriteRune(q[cur])
-			cur++
+	for i, v := range sizes {
+		if i > 0 {
+			buf.WriteByte(',')
 		}
+		buf.WriteString(strconv.Itoa(v))
+	}
+	return buf.String()
+}
 
-		buf.WriteString(placeholder(idx, param.name))
-		keys = append(keys, param.name)
-		cur = param.end + 1
+// Bind rewrites the query to the driver-correct sql, and collects args from params.
+// Slice values(except []byte) are expanded to a placeholder list, so `id IN (${ids})` works.
+func Bind(d DriverType, query string, params interface{}) (string, []interface{}, error) {
+	return parseTemplate(d, query).bind(params)
+}
+
+// args collects, encodes and expands the args of params.
+func (tpl *_Template) args(params interface{}) ([]interface{}, []int, error) {
+	args, err := ParamsToArgs(params, tpl.keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if args, err = encodeArgs(tpl.driverType, args); err != nil {
+		return nil, nil, err
 	}
+	args, sizes, err := expandArgs(tpl.driverType, tpl.keys, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return args, sizes, nil
+}
 
-	for cur < len(q) {
-		buf.WriteRune(q[cur])
-		cur++
+func (tpl *_Template) bind(params interface{}) (string, []interface{}, error) {
+	args, sizes, err := tpl.args(params)
+	if err != nil {
+		return "", nil, err
 	}
-	return buf.String(), keys
+	return tpl.render(sizes), args, nil
 }
@@ -1,6 +1,7 @@
 package sqlx
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -9,3 +10,126 @@ func Test_bindParams(t *testing.T) {
 	fmt.Println(BindParams(DriverTypeMysql, "select * from user where id=${id} and name='${aaa}'"))
 	fmt.Println(BindParams(DriverTypePostgres, "select * from user where id=${id} and name='${aaa}'"))
 }
+
+func TestBind_SliceParams(t *testing.T) {
+	type Arg struct {
+		Name string  `db:"name"`
+		IDs  []int64 `db:"ids"`
+	}
+
+	cases := []struct {
+		d      DriverType
+		params interface{}
+		query  string
+		args   int
+	}{
+		{DriverTypeMysql, Params{"name": "a", "ids": []int64{1, 2, 3}}, "select * from user where name=? and id in (?,?,?)", 4},
+		{DriverTypePostgres, map[string]interface{}{"name": "a", "ids": []string{"1", "2"}}, "select * from user where name=$1 and id in ($2,$3)", 3},
+		{DriverTypePostgres, Arg{Name: "a", IDs: []int64{7}}, "select * from user where name=$1 and id in ($2)", 2},
+		{DriverTypeMysql, Params{"name": []byte("a"), "ids": 1}, "select * from user where name=? and id in (?)", 2},
+	}
+	for _, c := range cases {
+		q, args, err := Bind(c.d, "select * from user where name=${name} and id in (${ids})", c.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q != c.query || len(args) != c.args {
+			t.Errorf("unexpected bind result: %s %v", q, args)
+		}
+	}
+
+	_, _, err := Bind(DriverTypeMysql, "select * from user where id in (${ids})", Params{"ids": []int{}})
+	if !errors.Is(err, ErrEmptySliceParam) {
+		t.Errorf("expected ErrEmptySliceParam, got %v", err)
+	}
+}
+
+func TestBind_PositionalParams(t *testing.T) {
+	query := "select * from user where id=${1} or parent=${1} or name=${2}"
+	q, args, err := Bind(DriverTypePostgres, query, ParamSlice{1, "a"})
+	if err != nil || q != "select * from user where id=$1 or parent=$1 or name=$2" || len(args) != 2 {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+	q, args, err = Bind(DriverTypeMysql, "select * from user where id=${} and name=${}", ParamSlice{1, "a"})
+	if err != nil || q != "select * from user where id=? and name=?" || args[1] != "a" {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+	q, args, err = Bind(DriverTypeMysql, "insert into user values (${name}, ${age})", ParamSlice{"a", 12})
+	if err != nil || args[0] != "a" || args[1] != 12 {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+
+	for _, params := range []ParamSlice{{1}, {1, "a", 3}} {
+		if _, _, err = Bind(DriverTypeMysql, query, params); err == nil {
+			t.Errorf("expected count mismatch error for %v", params)
+		}
+	}
+}
+
+func TestBind_ReusePlaceholder(t *testing.T) {
+	query := "select * from user where (id=${uid} or parent=${uid}) and group in (${gids}) and owner=${uid}"
+	params := Params{"uid": 1, "gids": []int{4, 5}}
+
+	q, args, err := Bind(DriverTypePostgres, query, params)
+	if err != nil || q != "select * from user where (id=$1 or parent=$1) and group in ($2,$3) and owner=$1" || len(args) != 3 {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+	q, args, err = Bind(DriverTypeMysql, query, params)
+	if err != nil || q != "select * from user where (id=? or parent=?) and group in (?,?) and owner=?" || len(args) != 5 {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+}
+
+func TestScanParams(t *testing.T) {
+	all := []DriverType{DriverTypePostgres, DriverTypeMysql, DriverTypeSqlite3}
+	cases := []struct {
+		name    string
+		drivers []DriverType
+		query   string
+		keys    []string
+	}{
+		{"plain", all, "select * from t where a=${a} and b=${b}", []string{"a", "b"}},
+		{"single quote", all, "select '${x}', ${a}", []string{"a"}},
+		{"doubled single quote", all, "select 'it''s ${x}', ${a}", []string{"a"}},
+		{"double quote", all, `select "${x}" from t where a=${a}`, []string{"a"}},
+		{"doubled double quote", all, `select "a""${x}" from t where a=${a}`, []string{"a"}},
+		{"line comment", all, "select * from t -- ${x}\nwhere a=${a}", []string{"a"}},
+		{"line comment at end", all, "select ${a} -- ${x}", []string{"a"}},
+		{"block comment", all, "select /* ${x} */ ${a}", []string{"a"}},
+		{"unterminated block comment", all, "select ${a} /* ${x}", []string{"a"}},
+		{"unterminated string", all, "select ${a}, '${x}", []string{"a"}},
+		{"unterminated param", all, "select ${a}, ${x", []string{"a"}},
+		{"empty params", all, "select ${}, ${ }", []string{"1", "2"}},
+
+		{"pg standard string keeps backslash", []DriverType{DriverTypePostgres}, `select 'C:\', ${a}, '\'`, []string{"a"}},
+		{"pg escape string", []DriverType{DriverTypePostgres}, `select E'\'${x}', ${a}`, []string{"a"}},
+		{"pg identifier ending with e", []DriverType{DriverTypePostgres}, `select name'\', ${a}`, []string{"a"}},
+		{"pg dollar quote", []DriverType{DriverTypePostgres}, "select $$ ${x} $$, ${a}", []string{"a"}},
+		{"pg tagged dollar quote", []DriverType{DriverTypePostgres}, "create function f() as $body$ select $$${x}$$; $body$; select ${a}", []string{"a"}},
+		{"pg positional placeholder", []DriverType{DriverTypePostgres}, "select $1, ${a}", []string{"a"}},
+		{"pg nested block comment", []DriverType{DriverTypePostgres}, "select /* /* ${x} */ ${y} */ ${a}", []string{"a"}},
+		{"pg backtick", []DriverType{DriverTypePostgres}, "select `${a}`", []string{"a"}},
+
+		{"mysql backslash escape", []DriverType{DriverTypeMysql}, `select 'a\'${x}', ${a}`, []string{"a"}},
+		{"mysql double quoted string", []DriverType{DriverTypeMysql}, `select "a\"${x}", ${a}`, []string{"a"}},
+		{"mysql backtick", []DriverType{DriverTypeMysql}, "select `${x}` from t where a=${a}", []string{"a"}},
+		{"mysql hash comment", []DriverType{DriverTypeMysql}, "select ${a} # ${x}\n, ${b}", []string{"a", "b"}},
+		{"mysql double dash without space", []DriverType{DriverTypeMysql}, "select 1--${a}", []string{"a"}},
+		{"mysql block comment not nested", []DriverType{DriverTypeMysql}, "select /* /* ${x} */ ${a} */", []string{"a"}},
+		{"mysql dollar", []DriverType{DriverTypeMysql}, "select $$ ${a} $$", []string{"a"}},
+
+		{"sqlite backslash", []DriverType{DriverTypeSqlite3}, `select 'C:\', ${a}`, []string{"a"}},
+		{"sqlite backtick", []DriverType{DriverTypeSqlite3}, "select `${x}`, ${a}", []string{"a"}},
+		{"sqlite bracket", []DriverType{DriverTypeSqlite3}, "select [${x}], ${a}", []string{"a"}},
+		{"sqlite hash", []DriverType{DriverTypeSqlite3}, "select ${a} # ${b}", []string{"a", "b"}},
+	}
+
+	for _, c := range cases {
+		for _, d := range c.drivers {
+			_, keys := BindParams(d, c.query)
+			if fmt.Sprint(keys) != fmt.Sprint(c.keys) {
+				t.Errorf("%s(driver %d): expected keys %v, got %v", c.name, d, c.keys, keys)
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var ErrEmptyRows = errors.New("sqlx: empty rows")
+
+// sqlInsertRows renders a multi-row INSERT with positional params.
+// A column missing in a row is filled by `DEFAULT`, which sqlite does not support.
+func (op *Operator) sqlInsertRows(d DriverType, columns []string, rows []Params, returning *Returning) (string, ParamSlice, error) {
+	var buf strings.Builder
+	buf.WriteString("INSERT INTO ")
+	buf.WriteString(op.model.TableName())
+	buf.WriteByte('(')
+	buf.WriteString(strings.Join(columns, ","))
+	buf.WriteString(") VALUES")
+
+	args := make(ParamSlice, 0, len(columns)*len(rows))
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('(')
+		for j, c := range columns {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			v, ok := row[c]
+			if !ok {
+				if d == DriverTypeSqlite3 {
+					return "", nil, fmt.Errorf("sqlx: column `%s` is missing in row %d", c, i)
+				}
+				buf.WriteString("DEFAULT")
+				continue
+			}
+			buf.WriteString("${}")
+			args = append(args, v)
+		}
+		buf.WriteByte(')')
+	}
+
+	if returning != nil {
+		buf.WriteString(" RETURNING ")
+		buf.WriteString(strings.Join(returning.Keys, ","))
+	}
+	return buf.String(), args, nil
+}
+
+// rowsToMaps converts a slice(or a slice pointer) of structs or maps, and returns the union of the columns.
+func rowsToMaps(rows interface{}, fill func(data Params, insert bool) Params) ([]Params, []string, error) {
+	rv := reflect.ValueOf(rows)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil, ErrNilParams
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, nil, fmt.Errorf("sqlx: rows should be a slice, got `%T`", rows)
+	}
+	if rv.Len() < 1 {
+		return nil, nil, ErrEmptyRows
+	}
+
+	lst := make([]Params, 0, rv.Len())
+	columns := map[string]bool{}
+	for i := 0; i < rv.Len(); i++ {
+		m, err := writeData(rv.Index(i).Interface(), true)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fill != nil {
+			m = fill(m, true)
+		}
+		if len(m) < 1 {
+			return nil, nil, ErrEmptyData
+		}
+		for k := range m {
+			columns[k] = true
+		}
+		lst = append(lst, m)
+	}
+
+	keys := make([]string, 0, len(columns))
+	for k := range columns {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return lst, keys, nil
+}
+
+// InsertMany inserts rows by multi-row INSERT, in chunks sized to stay under the placeholder limit of the driver.
+// rows is a slice of structs or maps. If there is more than one chunk and no ambient transaction,
+// the chunks are inserted in a new transaction. If returning is not nil, the returned rows are appended to returning.Rows.
+// If the driver does not support RETURNING, rows are inserted one by one to emulate it.
+func (op *Operator) InsertMany(ctx context.Context, rows interface{}, returning *Returning) (int64, error) {
+	var n int64
+	err := op.withHooks(ctx, rows, hookBeforeInsert, hookAfterInsert, func(ctx context.Context) error {
+		var err error
+		n, err = op.insertMany(ctx, rows, returning)
+		return err
+	})
+	return n, err
+}
+
+func (op *Operator) insertMany(ctx context.Context, rows interface{}, returning *Returning) (int64, error) {
+	lst, columns, err := rowsToMaps(rows, op.fillTimestamps)
+	if err != nil {
+		return 0, err
+	}
+	if returning != nil && returning.Rows == nil {
+		return 0, fmt.Errorf("sqlx: returning.Rows is required by InsertMany")
+	}
+
+	ctx, exe := PickExecutor(ctx)
+	d := exe.DriverType()
+	chunkSize := d.MaxPlaceholders() / len(columns)
+	if chunkSize < 1 {
+		return 0, fmt.Errorf("sqlx: too many columns, %d", len(columns))
+	}
+
+	emulated := returning != nil && !d.SupportsReturning()
+	if len(lst) <= chunkSize && !emulated {
+		return op.insertChunks(ctx, exe, lst, columns, chunkSize, returning)
+	}
+
+	var count int64
+	err = inTx(ctx, exe, func(ctx context.Context, exe Executor) error {
+		if !emulated {
+			count, err = op.insertChunks(ctx, exe, lst, columns, chunkSize, returning)
+			return err
+		}
+		// LastInsertId of a multi-row INSERT may be not consecutive, so insert row by row
+		for _, row := range lst {
+			if err := op.insertEmulated(ctx, exe, row.Keys(), row, returning); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (op *Operator) insertChunks(
+	ctx context.Context, exe Executor,
+	rows []Params, columns []string, chunkSize int,
+	returning *Returning,
+) (int64, error) {
+	var count int64
+	for begin := 0; begin < len(rows); begin += chunkSize {
+		end := begin + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		q, args, err := op.sqlInsertRows(exe.DriverType(), columns, rows[begin:end], returning)
+		if err != nil {
+			return count, err
+		}
+
+		if returning != nil {
+			if err = exe.Select(ctx, q, args, returning.Rows); err != nil {
+				return count, err
+			}
+			count += int64(end - begin)
+			continue
+		}
+
+		r, err := exe.Execute(ctx, q, args)
+		if err != nil {
+			return count, err
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return count, err
+		}
+		count += n
+	}
+	return count, nil
+}
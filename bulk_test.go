@@ -0,0 +1,37 @@
+package sqlx
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOperator_SqlInsertRows(t *testing.T) {
+	op := NewOperator(&testUser{})
+	nickname := "z"
+	rows, columns, err := rowsToMaps([]*testUser{{Name: "a"}, {Name: "b", Nickname: &nickname}}, op.fillTimestamps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(columns) != "[name nickname]" {
+		t.Errorf("unexpected columns: %v", columns)
+	}
+
+	q, args, err := op.sqlInsertRows(DriverTypePostgres, columns, rows, &Returning{Keys: []string{"id"}})
+	if err != nil || q != "INSERT INTO user(name,nickname) VALUES(${},DEFAULT),(${},${}) RETURNING id" || len(args) != 3 {
+		t.Errorf("unexpected query: %s %v %v", q, args, err)
+	}
+	bq, _, err := Bind(DriverTypePostgres, q, args)
+	if err != nil || bq != "INSERT INTO user(name,nickname) VALUES($1,DEFAULT),($2,$3) RETURNING id" {
+		t.Errorf("unexpected query: %s %v", bq, err)
+	}
+	if _, _, err = op.sqlInsertRows(DriverTypeSqlite3, columns, rows, nil); err == nil {
+		t.Errorf("expected missing column error")
+	}
+
+	if _, _, err = rowsToMaps([]testUser{}, nil); err != ErrEmptyRows {
+		t.Errorf("expected ErrEmptyRows, got %v", err)
+	}
+	if _, _, err = rowsToMaps(testUser{}, nil); err == nil {
+		t.Errorf("expected error")
+	}
+}
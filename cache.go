@@ -0,0 +1,98 @@
+package sqlx
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultTemplateCacheSize is the count of parsed queries cached by a new DB.
+var DefaultTemplateCacheSize = 512
+
+type _TemplateCacheKey struct {
+	driverType DriverType
+	query      string
+}
+
+type _TemplateCacheItem struct {
+	key _TemplateCacheKey
+	tpl *_Template
+}
+
+// _TemplateCache is a lru cache of parsed queries.
+type _TemplateCache struct {
+	hits   uint64 // keep 64-bit aligned for atomic
+	misses uint64
+	lock   sync.Mutex
+	size   int
+	lst    *list.List
+	items  map[_TemplateCacheKey]*list.Element
+}
+
+func newTemplateCache(size int) *_TemplateCache {
+	return &_TemplateCache{
+		size:  size,
+		lst:   list.New(),
+		items: map[_TemplateCacheKey]*list.Element{},
+	}
+}
+
+func (c *_TemplateCache) get(d DriverType, query string) *_Template {
+	key := _TemplateCacheKey{driverType: d, query: query}
+
+	c.lock.Lock()
+	ele, ok := c.items[key]
+	if ok {
+		c.lst.MoveToFront(ele)
+		c.lock.Unlock()
+		atomic.AddUint64(&c.hits, 1)
+		return ele.Value.(*_TemplateCacheItem).tpl
+	}
+	c.lock.Unlock()
+
+	atomic.AddUint64(&c.misses, 1)
+	tpl := parseTemplate(d, query)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ele, ok = c.items[key]; ok {
+		return ele.Value.(*_TemplateCacheItem).tpl
+	}
+	c.items[key] = c.lst.PushFront(&_TemplateCacheItem{key: key, tpl: tpl})
+	for c.lst.Len() > c.size {
+		last := c.lst.Back()
+		c.lst.Remove(last)
+		delete(c.items, last.Value.(*_TemplateCacheItem).key)
+	}
+	return tpl
+}
+
+func (c *_TemplateCache) stats() (uint64, uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
+func (db *DB) template(query string) *_Template {
+	c := db.tplCache
+	if c == nil {
+		return parseTemplate(db.driverType, query)
+	}
+	return c.get(db.driverType, query)
+}
+
+// SetTemplateCacheSize resets the cache of parsed queries, a size less than 1 disables the cache.
+// It is not safe to call this concurrently with queries.
+func (db *DB) SetTemplateCacheSize(size int) {
+	if size < 1 {
+		db.tplCache = nil
+		return
+	}
+	db.tplCache = newTemplateCache(size)
+}
+
+// TemplateCacheStats returns the hit and miss counters of the cache of parsed queries.
+func (db *DB) TemplateCacheStats() (hits uint64, misses uint64) {
+	if db.tplCache == nil {
+		return 0, 0
+	}
+	return db.tplCache.stats()
+}
@@ -0,0 +1,25 @@
+package sqlx
+
+import "testing"
+
+func TestDB_TemplateCache(t *testing.T) {
+	db := &DB{driverType: DriverTypePostgres}
+	db.SetTemplateCacheSize(2)
+
+	for _, q := range []string{"a=${a}", "b=${b}", "a=${a}", "c=${c}", "b=${b}"} {
+		if _, _, err := db.BindParams(q, Params{"a": 1, "b": 2, "c": 3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits, misses := db.TemplateCacheStats(); hits != 1 || misses != 4 {
+		t.Errorf("unexpected cache stats: %d %d", hits, misses)
+	}
+
+	db.SetTemplateCacheSize(0)
+	if q, _, _ := db.BindParams("a=${a}", Params{"a": 1}); q != "a=$1" {
+		t.Errorf("unexpected query: %s", q)
+	}
+	if hits, misses := db.TemplateCacheStats(); hits != 0 || misses != 0 {
+		t.Errorf("unexpected cache stats: %d %d", hits, misses)
+	}
+}
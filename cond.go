@@ -0,0 +1,303 @@
+package sqlx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zzztttkkk/sqlx/reflectx"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Cond is a composable WHERE condition, rendered by Operator.Where.
+type Cond interface {
+	build(b *_CondBuilder) error
+}
+
+type _CondBuilder struct {
+	buf    strings.Builder
+	params Params
+	smap   *reflectx.StructMap
+}
+
+// CondParamPrefix is the prefix of the param names generated by conditions.
+const CondParamPrefix = "__c"
+
+// _Column is the column of a condition, with the call site building the condition, for the error messages.
+type _Column struct {
+	name string
+	at   string
+}
+
+var columnNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// newColumn is called by the condition constructors, so the call site is the caller of the constructor.
+func newColumn(name string) _Column {
+	c := _Column{name: name}
+	if _, file, line, ok := runtime.Caller(2); ok {
+		c.at = filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+	return c
+}
+
+func (c _Column) check() error {
+	if !columnNameRegexp.MatchString(c.name) {
+		return fmt.Errorf("sqlx: bad column name `%s`, at %s", c.name, c.at)
+	}
+	return nil
+}
+
+func (b *_CondBuilder) column(c _Column) error {
+	if _, ok := b.smap.Names[c.name]; !ok {
+		return fmt.Errorf("sqlx: unknown column `%s`, at %s", c.name, c.at)
+	}
+	b.buf.WriteString(c.name)
+	return nil
+}
+
+// _ErrCond is a condition failed to be built, the error is returned by Operator.Where.
+type _ErrCond struct {
+	err error
+}
+
+func (c *_ErrCond) build(b *_CondBuilder) error { return c.err }
+
+func (b *_CondBuilder) param(v interface{}) {
+	if b.params == nil {
+		b.params = Params{}
+	}
+	name := CondParamPrefix + strconv.Itoa(len(b.params))
+	b.params[name] = v
+	b.buf.WriteString("${")
+	b.buf.WriteString(name)
+	b.buf.WriteByte('}')
+}
+
+type _CompareCond struct {
+	column _Column
+	op     string
+	value  interface{}
+}
+
+func (c *_CompareCond) build(b *_CondBuilder) error {
+	if err := b.column(c.column); err != nil {
+		return err
+	}
+	b.buf.WriteString(c.op)
+	b.param(c.value)
+	return nil
+}
+
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// compare builds the comparison. Comparing with NULL never matches, so Eq and Ne of nil render
+// `IS NULL` and `IS NOT NULL`, and the other comparisons of nil fail.
+func compare(c _Column, op string, v interface{}) Cond {
+	if err := c.check(); err != nil {
+		return &_ErrCond{err: err}
+	}
+	if isNull(v) {
+		switch op {
+		case "=":
+			return &_NullCond{column: c}
+		case "<>":
+			return &_NullCond{column: c, not: true}
+		}
+		return &_ErrCond{err: fmt.Errorf("sqlx: can not compare column `%s` with NULL, at %s", c.name, c.at)}
+	}
+	return &_CompareCond{column: c, op: op, value: v}
+}
+
+func Eq(column string, v interface{}) Cond   { return compare(newColumn(column), "=", v) }
+func Ne(column string, v interface{}) Cond   { return compare(newColumn(column), "<>", v) }
+func Gt(column string, v interface{}) Cond   { return compare(newColumn(column), ">", v) }
+func Gte(column string, v interface{}) Cond  { return compare(newColumn(column), ">=", v) }
+func Lt(column string, v interface{}) Cond   { return compare(newColumn(column), "<", v) }
+func Lte(column string, v interface{}) Cond  { return compare(newColumn(column), "<=", v) }
+func Like(column string, v interface{}) Cond { return compare(newColumn(column), " LIKE ", v) }
+
+type _InCond struct {
+	column _Column
+	values interface{}
+	not    bool
+}
+
+var ErrEmptyIn = errors.New("sqlx: empty values of IN condition")
+
+func (c *_InCond) build(b *_CondBuilder) error {
+	rv, ok := isExpandable(c.values)
+	if !ok {
+		return fmt.Errorf("sqlx: values of IN condition should be a slice, got `%T`", c.values)
+	}
+	if rv.Len() < 1 {
+		return ErrEmptyIn
+	}
+	if err := b.column(c.column); err != nil {
+		return err
+	}
+	if c.not {
+		b.buf.WriteString(" NOT")
+	}
+	b.buf.WriteString(" IN (")
+	b.param(c.values)
+	b.buf.WriteByte(')')
+	return nil
+}
+
+// In renders `column IN (...)`, values should be a non-empty slice.
+func In(column string, values interface{}) Cond { return inCond(newColumn(column), values, false) }
+
+func NotIn(column string, values interface{}) Cond { return inCond(newColumn(column), values, true) }
+
+func inCond(c _Column, values interface{}, not bool) Cond {
+	if err := c.check(); err != nil {
+		return &_ErrCond{err: err}
+	}
+	return &_InCond{column: c, values: values, not: not}
+}
+
+type _BetweenCond struct {
+	column _Column
+	begin  interface{}
+	end    interface{}
+}
+
+func (c *_BetweenCond) build(b *_CondBuilder) error {
+	if err := b.column(c.column); err != nil {
+		return err
+	}
+	b.buf.WriteString(" BETWEEN ")
+	b.param(c.begin)
+	b.buf.WriteString(" AND ")
+	b.param(c.end)
+	return nil
+}
+
+func Between(column string, begin, end interface{}) Cond {
+	c := newColumn(column)
+	if err := c.check(); err != nil {
+		return &_ErrCond{err: err}
+	}
+	return &_BetweenCond{column: c, begin: begin, end: end}
+}
+
+type _NullCond struct {
+	column _Column
+	not    bool
+}
+
+func (c *_NullCond) build(b *_CondBuilder) error {
+	if err := b.column(c.column); err != nil {
+		return err
+	}
+	if c.not {
+		b.buf.WriteString(" IS NOT NULL")
+	} else {
+		b.buf.WriteString(" IS NULL")
+	}
+	return nil
+}
+
+func IsNull(column string) Cond { return nullCond(newColumn(column), false) }
+
+func NotNull(column string) Cond { return nullCond(newColumn(column), true) }
+
+func nullCond(c _Column, not bool) Cond {
+	if err := c.check(); err != nil {
+		return &_ErrCond{err: err}
+	}
+	return &_NullCond{column: c, not: not}
+}
+
+type _GroupCond struct {
+	sep   string
+	conds []Cond
+}
+
+func (c *_GroupCond) build(b *_CondBuilder) error {
+	if len(c.conds) < 1 { // an empty AND matches all rows, an empty OR matches nothing
+		if c.sep == " AND " {
+			b.buf.WriteString("1=1")
+		} else {
+			b.buf.WriteString("1=0")
+		}
+		return nil
+	}
+	b.buf.WriteByte('(')
+	for i, v := range c.conds {
+		if i > 0 {
+			b.buf.WriteString(c.sep)
+		}
+		if err := v.build(b); err != nil {
+			return err
+		}
+	}
+	b.buf.WriteByte(')')
+	return nil
+}
+
+// skipNil drops the nil conditions, so optional filters can be passed as nil.
+func skipNil(conds []Cond) []Cond {
+	lst := make([]Cond, 0, len(conds))
+	for _, v := range conds {
+		if v != nil {
+			lst = append(lst, v)
+		}
+	}
+	return lst
+}
+
+// And joins the conditions with AND, nil conditions are skipped.
+func And(conds ...Cond) Cond { return &_GroupCond{sep: " AND ", conds: skipNil(conds)} }
+
+// Or joins the conditions with OR, nil conditions are skipped.
+func Or(conds ...Cond) Cond { return &_GroupCond{sep: " OR ", conds: skipNil(conds)} }
+
+type _NotCond struct {
+	cond Cond
+}
+
+func (c *_NotCond) build(b *_CondBuilder) error {
+	if c.cond == nil {
+		return ErrEmptyCondition
+	}
+	b.buf.WriteString("NOT (")
+	if err := c.cond.build(b); err != nil {
+		return err
+	}
+	b.buf.WriteByte(')')
+	return nil
+}
+
+// Not negates the condition, a nil condition fails with ErrEmptyCondition.
+func Not(cond Cond) Cond { return &_NotCond{cond: cond} }
+
+// Where renders the condition to sql with `${}` params, which can be passed to Get/Select/Update/Delete.
+// Columns are validated against the model.
+func (op *Operator) Where(cond Cond) (string, Params, error) {
+	if cond == nil {
+		return "", nil, ErrEmptyCondition
+	}
+	b := &_CondBuilder{smap: op.smap}
+	if err := cond.build(b); err != nil {
+		return "", nil, err
+	}
+	return b.buf.String(), b.params, nil
+}
+
+func (op *Operator) MustWhere(cond Cond) (string, Params) {
+	q, p, e := op.Where(cond)
+	if e != nil {
+		panic(e)
+	}
+	return q, p
+}
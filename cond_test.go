@@ -0,0 +1,75 @@
+package sqlx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOperator_Where(t *testing.T) {
+	op := NewOperator(&testUser{})
+
+	q, p, err := op.Where(And(
+		Eq("name", "ztk"),
+		Or(In("id", []int64{1, 2}), Between("age", 10, 20), Not(Like("name", "a%"))),
+		IsNull("nickname"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "(name=${__c0} AND (id IN (${__c1}) OR age BETWEEN ${__c2} AND ${__c3} OR NOT (name LIKE ${__c4})) AND nickname IS NULL)"
+	if q != expected || len(p) != 5 {
+		t.Errorf("unexpected condition: %s %v", q, p)
+	}
+
+	bq, args, err := Bind(DriverTypePostgres, q, p)
+	if err != nil || len(args) != 6 {
+		t.Errorf("unexpected bind result: %s %v %v", bq, args, err)
+	}
+
+	if q, _, _ = op.Where(And()); q != "1=1" {
+		t.Errorf("unexpected condition: %s", q)
+	}
+	if _, _, err = op.Where(Eq("nmae", 1)); err == nil {
+		t.Errorf("expected unknown column error")
+	}
+	if _, _, err = op.Where(In("id", []int{})); !errors.Is(err, ErrEmptyIn) {
+		t.Errorf("expected ErrEmptyIn, got %v", err)
+	}
+	if _, _, err = op.Where(In("id", 1)); err == nil {
+		t.Errorf("expected error")
+	}
+	if _, _, err = op.Where(nil); !errors.Is(err, ErrEmptyCondition) {
+		t.Errorf("expected ErrEmptyCondition, got %v", err)
+	}
+
+	if q, _, err = op.Where(And(nil, Eq("id", 1), Or(nil))); err != nil || q != "(id=${__c0} AND 1=0)" {
+		t.Errorf("unexpected condition: %s %v", q, err)
+	}
+	if q, _, err = op.Where(Or(nil, nil)); err != nil || q != "1=0" {
+		t.Errorf("unexpected condition: %s %v", q, err)
+	}
+	if _, _, err = op.Where(And(Eq("id", 1), Not(nil))); !errors.Is(err, ErrEmptyCondition) {
+		t.Errorf("expected ErrEmptyCondition, got %v", err)
+	}
+}
+
+func TestCond_NullAndColumns(t *testing.T) {
+	op := NewOperator(&testUser{})
+	var nick *string
+	q, p, err := op.Where(And(Eq("nickname", nil), Ne("name", nick), Eq("id", 1)))
+	if err != nil || q != "(nickname IS NULL AND name IS NOT NULL AND id=${__c0})" || len(p) != 1 {
+		t.Errorf("unexpected condition: %s %v %v", q, p, err)
+	}
+	if _, _, err = op.Where(Gt("age", nil)); err == nil || !strings.Contains(err.Error(), "NULL") {
+		t.Errorf("expected NULL comparison error, got %v", err)
+	}
+
+	// the errors point at the call building the condition
+	if _, ok := Eq("id; DROP TABLE user", 1).(*_ErrCond); !ok {
+		t.Errorf("bad column name is not rejected")
+	}
+	if _, _, err = op.Where(Or(Eq("id", 1), In("nmae", []int{1}))); err == nil || !strings.Contains(err.Error(), "cond_test.go:") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
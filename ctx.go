@@ -13,6 +13,9 @@ const (
 	_KeyDB = _Key(iota + 1)
 	_KeyJustWDB
 	_KeyTx
+	_KeyAllowImmutable
+	_KeyUnscoped
+	_KeyUnversioned
 )
 
 var wDB *DB
@@ -87,10 +90,14 @@ type TxOptions struct {
 func MustBegin(ctx context.Context, options *TxOptions) (context.Context, *Tx) {
 	exe := getExe(ctx)
 	var rTx *Tx
-	if exe != nil { // options can not be nil
+	if exe != nil {
 		tx, ok := exe.(*Tx)
 		if ok {
-			rTx = tx.MustBeginTx(ctx, options.Savepoint)
+			var savepoint string
+			if options != nil {
+				savepoint = options.Savepoint
+			}
+			rTx = tx.MustBeginTx(ctx, savepoint)
 		}
 	}
 	if rTx == nil { // options can be nil
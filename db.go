@@ -15,15 +15,17 @@ type DB struct {
 	std        *sql.DB
 	driverType DriverType
 	logger     Logger
+	tplCache   *_TemplateCache
 }
 
 func (db *DB) Raw() *sql.DB { return db.std }
 
 func (db *DB) SetLogger(v Logger) { db.logger = v }
 
+func (db *DB) DriverType() DriverType { return db.driverType }
+
 func (db *DB) BindParams(query string, params interface{}) (string, []interface{}, error) {
-	q, keys := BindParams(db.driverType, query)
-	args, err := ParamsToArgs(params, keys)
+	q, args, err := db.template(query).bind(params)
 	if err != nil {
 		return "", nil, err
 	}
@@ -104,7 +106,8 @@ func (db *DB) MustBeginTx(ctx context.Context, opt *sql.TxOptions) *Tx {
 }
 
 func (db *DB) Prepare(ctx context.Context, query string) (*Stmt, error) {
-	query, keys := BindParams(db.driverType, query)
+	tpl := db.template(query)
+	query = tpl.query
 	stmt, err := db.std.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -113,9 +116,10 @@ func (db *DB) Prepare(ctx context.Context, query string) (*Stmt, error) {
 		db.logger.Printf("stmt prepared by db: %s, sql.Stmt(%p)", query, stmt)
 	}
 	return &Stmt{
-		std:    stmt,
-		keys:   keys,
-		logger: db.logger,
+		std:     stmt,
+		tpl:     tpl,
+		prepare: db.std.PrepareContext,
+		logger:  db.logger,
 	}, nil
 }
 
@@ -126,7 +130,9 @@ func Open(driverName string, dsn string) (*DB, error) {
 	if e != nil {
 		return nil, e
 	}
-	return &DB{std: db, driverType: nameToDriverType(driverName)}, nil
+	v := &DB{std: db, driverType: nameToDriverType(driverName)}
+	v.SetTemplateCacheSize(DefaultTemplateCacheSize)
+	return v, nil
 }
 
 func MustOpen(driverName string, dsn string) *DB {
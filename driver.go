@@ -2,6 +2,7 @@ package sqlx
 
 import (
 	"fmt"
+	"strings"
 )
 
 type DriverType int
@@ -24,6 +25,82 @@ func (t DriverType) PlaceholderFunc() func(idx int, name string) string {
 	}
 }
 
+// ReusablePlaceholder reports whether a placeholder can be referenced many times in one query, like `$1` of postgres.
+func (t DriverType) ReusablePlaceholder() bool {
+	return t == DriverTypePostgres
+}
+
+// LimitClause returns the ` LIMIT ... OFFSET ...` clause, a limit less than 1 means no limit.
+func (t DriverType) LimitClause(limit, offset int64) string {
+	if limit < 1 && offset < 1 {
+		return ""
+	}
+	switch t {
+	case DriverTypeMysql:
+		if offset < 1 {
+			return fmt.Sprintf(" LIMIT %d", limit)
+		}
+		if limit < 1 {
+			return fmt.Sprintf(" LIMIT %d, 18446744073709551615", offset)
+		}
+		return fmt.Sprintf(" LIMIT %d, %d", offset, limit)
+	case DriverTypeSqlite3:
+		if limit < 1 {
+			limit = -1
+		}
+		if offset < 1 {
+			return fmt.Sprintf(" LIMIT %d", limit)
+		}
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	default:
+		var buf strings.Builder
+		if limit > 0 {
+			buf.WriteString(fmt.Sprintf(" LIMIT %d", limit))
+		}
+		if offset > 0 {
+			buf.WriteString(fmt.Sprintf(" OFFSET %d", offset))
+		}
+		return buf.String()
+	}
+}
+
+// QuoteIdentifier quotes a name, like a savepoint name, the quote characters in it are escaped by doubling.
+func (t DriverType) QuoteIdentifier(name string) string {
+	q := "\""
+	if t == DriverTypeMysql {
+		q = "`"
+	}
+	return q + strings.ReplaceAll(name, q, q+q) + q
+}
+
+// Sqlite3MaxPlaceholders is SQLITE_MAX_VARIABLE_NUMBER, which is 32766 since sqlite 3.32.0.
+var Sqlite3MaxPlaceholders = 999
+
+// MaxPlaceholders returns the max count of placeholders in one query.
+func (t DriverType) MaxPlaceholders() int {
+	switch t {
+	case DriverTypeSqlite3:
+		return Sqlite3MaxPlaceholders
+	default:
+		return 65535
+	}
+}
+
+// Sqlite3SupportsReturning should be set to false for sqlite before 3.35.0, which adds RETURNING.
+var Sqlite3SupportsReturning = true
+
+// SupportsReturning reports whether the driver supports `RETURNING`, Operator emulates it if not.
+func (t DriverType) SupportsReturning() bool {
+	switch t {
+	case DriverTypePostgres:
+		return true
+	case DriverTypeSqlite3:
+		return Sqlite3SupportsReturning
+	default:
+		return false
+	}
+}
+
 func nameToDriverType(name string) DriverType {
 	switch name {
 	case "mysql":
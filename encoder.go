@@ -0,0 +1,185 @@
+package sqlx
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Encoder converts a param value to a value the driver accepts.
+type Encoder func(v interface{}) (interface{}, error)
+
+type _EncoderKey struct {
+	driverType DriverType
+	t          reflect.Type
+}
+
+var encodersLock sync.Mutex
+var encoders atomic.Value // map[_EncoderKey]Encoder, copied on write
+
+func registerEncoder(key _EncoderKey, encoder Encoder) {
+	encodersLock.Lock()
+	defer encodersLock.Unlock()
+
+	old, _ := encoders.Load().(map[_EncoderKey]Encoder)
+	m := make(map[_EncoderKey]Encoder, len(old)+1)
+	for k, v := range old {
+		m[k] = v
+	}
+	if encoder == nil {
+		delete(m, key)
+	} else {
+		m[key] = encoder
+	}
+	encoders.Store(m)
+}
+
+// RegisterEncoder registers the encoder of type t for all drivers, a nil encoder removes it.
+// Encoders are applied to the whole arg first, like EncodePgArray for a slice type,
+// then to each element of the expanded slice params.
+func RegisterEncoder(t reflect.Type, encoder Encoder) {
+	registerEncoder(_EncoderKey{driverType: DriverTypeUnknown, t: t}, encoder)
+}
+
+// RegisterDriverEncoder registers the encoder of type t for the driver, it takes precedence over RegisterEncoder.
+func RegisterDriverEncoder(d DriverType, t reflect.Type, encoder Encoder) {
+	registerEncoder(_EncoderKey{driverType: d, t: t}, encoder)
+}
+
+func loadEncoders() map[_EncoderKey]Encoder {
+	m, _ := encoders.Load().(map[_EncoderKey]Encoder)
+	return m
+}
+
+func encodeArg(m map[_EncoderKey]Encoder, d DriverType, arg interface{}) (interface{}, error) {
+	if arg == nil || len(m) < 1 {
+		return arg, nil
+	}
+	t := reflect.TypeOf(arg)
+	encoder, ok := m[_EncoderKey{driverType: d, t: t}]
+	if !ok {
+		encoder, ok = m[_EncoderKey{driverType: DriverTypeUnknown, t: t}]
+	}
+	if !ok {
+		return arg, nil
+	}
+	return encoder(arg)
+}
+
+func encodeArgs(d DriverType, args []interface{}) ([]interface{}, error) {
+	m := loadEncoders()
+	if len(m) < 1 {
+		return args, nil
+	}
+	for i, arg := range args {
+		v, err := encodeArg(m, d, arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// EncodePgArray encodes a slice to a postgres array literal, like `{1,2,"a b"}`.
+// Register it for slice types to bind them as arrays instead of placeholder lists:
+//
+//	RegisterDriverEncoder(DriverTypePostgres, reflect.TypeOf([]int64{}), EncodePgArray)
+func EncodePgArray(v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("sqlx: can not encode `%T` as a postgres array", v)
+	}
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return nil, nil
+	}
+	var buf strings.Builder
+	if err := writePgArray(&buf, rv); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+func writePgArray(buf *strings.Builder, rv reflect.Value) error {
+	buf.WriteByte('{')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writePgArrayElement(buf, rv.Index(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writePgArrayElement(buf *strings.Builder, ev reflect.Value) error {
+	for ev.Kind() == reflect.Ptr || ev.Kind() == reflect.Interface {
+		if ev.IsNil() {
+			buf.WriteString("NULL")
+			return nil
+		}
+		ev = ev.Elem()
+	}
+
+	v := ev.Interface()
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		if dv == nil {
+			buf.WriteString("NULL")
+			return nil
+		}
+		ev = reflect.ValueOf(dv)
+		v = dv
+	}
+
+	switch tv := v.(type) {
+	case string:
+		writePgArrayString(buf, tv)
+		return nil
+	case []byte:
+		writePgArrayString(buf, string(tv))
+		return nil
+	case time.Time:
+		writePgArrayString(buf, tv.Format(time.RFC3339Nano))
+		return nil
+	}
+
+	switch ev.Kind() {
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(ev.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(ev.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		buf.WriteString(strconv.FormatUint(ev.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(ev.Float(), 'g', -1, 64))
+	case reflect.String:
+		writePgArrayString(buf, ev.String())
+	case reflect.Slice, reflect.Array:
+		return writePgArray(buf, ev)
+	default:
+		return fmt.Errorf("sqlx: can not encode `%T` as a postgres array element", v)
+	}
+	return nil
+}
+
+func writePgArrayString(buf *strings.Builder, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(r)
+	}
+	buf.WriteByte('"')
+}
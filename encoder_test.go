@@ -0,0 +1,76 @@
+package sqlx
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncoders(t *testing.T) {
+	durationType := reflect.TypeOf(time.Duration(0))
+	idsType := reflect.TypeOf([]int64{})
+	RegisterEncoder(durationType, func(v interface{}) (interface{}, error) { return int64(v.(time.Duration) / time.Millisecond), nil })
+	RegisterDriverEncoder(DriverTypePostgres, idsType, EncodePgArray)
+	defer func() {
+		RegisterEncoder(durationType, nil)
+		RegisterDriverEncoder(DriverTypePostgres, idsType, nil)
+	}()
+
+	params := Params{"ttl": time.Second, "ids": []int64{1, 2}}
+	q, args, err := Bind(DriverTypePostgres, "select ${ttl}, ${ids}", params)
+	if err != nil || q != "select $1, $2" || fmt.Sprint(args) != "[1000 {1,2}]" {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+	q, args, err = Bind(DriverTypeMysql, "select ${ttl}, ${ids}", params)
+	if err != nil || q != "select ?, ?,?" || fmt.Sprint(args) != "[1000 1 2]" {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+}
+
+type testStatus string
+
+func TestEncoders_SliceElements(t *testing.T) {
+	statusType := reflect.TypeOf(testStatus(""))
+	RegisterEncoder(statusType, func(v interface{}) (interface{}, error) { return "status:" + string(v.(testStatus)), nil })
+	defer RegisterEncoder(statusType, nil)
+
+	params := Params{"status": testStatus("a"), "statuses": []testStatus{"b", "c"}, "any": []interface{}{testStatus("d"), 1}}
+	q, args, err := Bind(DriverTypePostgres, "select ${status}, ${statuses}, ${any}", params)
+	if err != nil || q != "select $1, $2,$3, $4,$5" || fmt.Sprint(args) != "[status:a status:b status:c status:d 1]" {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+
+	// the whole slice is encoded first, so the elements of an array are not encoded again
+	statusesType := reflect.TypeOf([]testStatus{})
+	RegisterDriverEncoder(DriverTypePostgres, statusesType, EncodePgArray)
+	defer RegisterDriverEncoder(DriverTypePostgres, statusesType, nil)
+	q, args, err = Bind(DriverTypePostgres, "select ${statuses}", params)
+	if err != nil || q != "select $1" || fmt.Sprint(args) != `[{"b","c"}]` {
+		t.Errorf("unexpected bind result: %s %v %v", q, args, err)
+	}
+}
+
+func TestEncodePgArray(t *testing.T) {
+	s := "x"
+	cases := []struct {
+		v      interface{}
+		result interface{}
+	}{
+		{[]int{1, 2}, "{1,2}"},
+		{[]string{`a "b"`, `c\d`}, `{"a \"b\"","c\\d"}`},
+		{[]*string{&s, nil}, `{"x",NULL}`},
+		{[][]float64{{1.5}, {2}}, "{{1.5},{2}}"},
+		{[]bool{true}, "{true}"},
+		{[]int(nil), nil},
+	}
+	for _, c := range cases {
+		v, err := EncodePgArray(c.v)
+		if err != nil || v != c.result {
+			t.Errorf("unexpected result of %v: %v %v", c.v, v, err)
+		}
+	}
+	if _, err := EncodePgArray(1); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 )
 
 type BasicExecutor interface {
+	DriverType() DriverType
 	BindParams(query string, params interface{}) (string, []interface{}, error)
 	Execute(ctx context.Context, query string, params interface{}) (sql.Result, error)
 	Rows(ctx context.Context, query string, params interface{}) (*Rows, error)
@@ -1,3 +1,5 @@
 module github.com/zzztttkkk/sqlx
 
 go 1.16
+
+require github.com/mattn/go-sqlite3 v1.14.19
@@ -0,0 +1,200 @@
+package sqlx
+
+import (
+	"context"
+	"reflect"
+)
+
+// Models implementing the hook interfaces below are called back by the Operator.
+// Write hooks run in the same transaction as the write, a new one is begun if the context has none,
+// so hooks can run extra queries by the executor. An error from a hook aborts and rolls back the write.
+//
+// Hooks are called on the written value itself, so they only fire if the data(or the params of Delete)
+// is a model pointer, or a slice of models for InsertMany. Params, maps and struct values have no hook,
+// and the write runs without the wrapping transaction.
+
+type BeforeInsertHook interface {
+	BeforeInsert(ctx context.Context, exe Executor) error
+}
+
+type AfterInsertHook interface {
+	AfterInsert(ctx context.Context, exe Executor) error
+}
+
+type BeforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context, exe Executor) error
+}
+
+type AfterUpdateHook interface {
+	AfterUpdate(ctx context.Context, exe Executor) error
+}
+
+// BeforeDeleteHook is called on the params of Delete, if the params is the model.
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context, exe Executor) error
+}
+
+// AfterDeleteHook is called on the params of Delete, if the params is the model.
+type AfterDeleteHook interface {
+	AfterDelete(ctx context.Context, exe Executor) error
+}
+
+// AfterFindHook is called on each row loaded by Get, Select and SelectPage.
+type AfterFindHook interface {
+	AfterFind(ctx context.Context, exe Executor) error
+}
+
+type _Hook int
+
+const (
+	hookBeforeInsert = _Hook(1 << iota)
+	hookAfterInsert
+	hookBeforeUpdate
+	hookAfterUpdate
+	hookBeforeDelete
+	hookAfterDelete
+	hookAfterFind
+)
+
+var hookTypes = map[_Hook]reflect.Type{
+	hookBeforeInsert: reflect.TypeOf((*BeforeInsertHook)(nil)).Elem(),
+	hookAfterInsert:  reflect.TypeOf((*AfterInsertHook)(nil)).Elem(),
+	hookBeforeUpdate: reflect.TypeOf((*BeforeUpdateHook)(nil)).Elem(),
+	hookAfterUpdate:  reflect.TypeOf((*AfterUpdateHook)(nil)).Elem(),
+	hookBeforeDelete: reflect.TypeOf((*BeforeDeleteHook)(nil)).Elem(),
+	hookAfterDelete:  reflect.TypeOf((*AfterDeleteHook)(nil)).Elem(),
+	hookAfterFind:    reflect.TypeOf((*AfterFindHook)(nil)).Elem(),
+}
+
+func detectHooks(t reflect.Type) _Hook {
+	var hooks _Hook
+	for h, ht := range hookTypes {
+		if t.Implements(ht) {
+			hooks |= h
+		}
+	}
+	return hooks
+}
+
+func hasHook(target interface{}, hook _Hook) bool {
+	t := reflect.TypeOf(target)
+	return t != nil && t.Implements(hookTypes[hook])
+}
+
+func callHook(ctx context.Context, exe Executor, hook _Hook, target interface{}) error {
+	switch hook {
+	case hookBeforeInsert:
+		if h, ok := target.(BeforeInsertHook); ok {
+			return h.BeforeInsert(ctx, exe)
+		}
+	case hookAfterInsert:
+		if h, ok := target.(AfterInsertHook); ok {
+			return h.AfterInsert(ctx, exe)
+		}
+	case hookBeforeUpdate:
+		if h, ok := target.(BeforeUpdateHook); ok {
+			return h.BeforeUpdate(ctx, exe)
+		}
+	case hookAfterUpdate:
+		if h, ok := target.(AfterUpdateHook); ok {
+			return h.AfterUpdate(ctx, exe)
+		}
+	case hookBeforeDelete:
+		if h, ok := target.(BeforeDeleteHook); ok {
+			return h.BeforeDelete(ctx, exe)
+		}
+	case hookAfterDelete:
+		if h, ok := target.(AfterDeleteHook); ok {
+			return h.AfterDelete(ctx, exe)
+		}
+	case hookAfterFind:
+		if h, ok := target.(AfterFindHook); ok {
+			return h.AfterFind(ctx, exe)
+		}
+	}
+	return nil
+}
+
+// hookTargets returns the hook receivers of the data, each element if it is a slice, or the data itself.
+func hookTargets(data interface{}) []interface{} {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return []interface{}{data}
+	}
+	lst := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		ev := v.Index(i)
+		if ev.Kind() != reflect.Ptr && ev.CanAddr() {
+			ev = ev.Addr()
+		}
+		lst = append(lst, ev.Interface())
+	}
+	return lst
+}
+
+// withHooks runs fn between the before and after hooks of the data, in a transaction.
+// If the data has none of the hooks, fn runs directly.
+func (op *Operator) withHooks(
+	ctx context.Context, data interface{},
+	before, after _Hook,
+	fn func(ctx context.Context) error,
+) error {
+	if op.hooks&(before|after) == 0 {
+		return fn(ctx)
+	}
+	var targets []interface{}
+	for _, target := range hookTargets(data) {
+		if hasHook(target, before) || hasHook(target, after) {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) < 1 {
+		return fn(ctx)
+	}
+	ctx, exe := PickExecutor(ctx)
+	return inTx(ctx, exe, func(ctx context.Context, exe Executor) error {
+		for _, target := range targets {
+			if err := callHook(ctx, exe, before, target); err != nil {
+				return err
+			}
+		}
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		for _, target := range targets {
+			if err := callHook(ctx, exe, after, target); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// afterFind calls AfterFind on the rows of dist, which are appended after the `begin` index if it is a slice.
+func (op *Operator) afterFind(ctx context.Context, exe Executor, dist interface{}, begin int) error {
+	if op.hooks&hookAfterFind == 0 {
+		return nil
+	}
+	targets := hookTargets(dist)
+	if begin > len(targets) {
+		begin = len(targets)
+	}
+	for _, target := range targets[begin:] {
+		if err := callHook(ctx, exe, hookAfterFind, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sliceLen returns the length of the slice pointed by dist, or 0.
+func sliceLen(dist interface{}) int {
+	v := reflect.ValueOf(dist)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return 0
+	}
+	return v.Elem().Len()
+}
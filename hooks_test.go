@@ -0,0 +1,159 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testHooked struct {
+	ID    int64 `db:"id"`
+	found bool
+}
+
+func (h *testHooked) TableName() string { return "hooked" }
+
+func (h *testHooked) TableColumns() []string { return nil }
+
+func (h *testHooked) BeforeInsert(ctx context.Context, exe Executor) error {
+	if h.ID < 0 {
+		return errors.New("negative id")
+	}
+	return nil
+}
+
+func (h *testHooked) AfterFind(ctx context.Context, exe Executor) error {
+	h.found = true
+	return nil
+}
+
+func TestOperator_Hooks(t *testing.T) {
+	op := NewOperator(&testHooked{})
+	if op.hooks != hookBeforeInsert|hookAfterFind {
+		t.Errorf("unexpected hooks: %b", op.hooks)
+	}
+	if NewOperator(&testUser{}).hooks != 0 {
+		t.Errorf("unexpected hooks")
+	}
+
+	err := callHook(context.Background(), nil, hookBeforeInsert, &testHooked{ID: -1})
+	if err == nil || err.Error() != "negative id" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	lst := []testHooked{{ID: 1}, {ID: 2}, {ID: 3}}
+	if err = op.afterFind(context.Background(), nil, &lst, 1); err != nil {
+		t.Error(err)
+	}
+	if lst[0].found || !lst[1].found || !lst[2].found {
+		t.Errorf("unexpected rows: %v", lst)
+	}
+	one := &testHooked{}
+	if err = op.afterFind(context.Background(), nil, one, 0); err != nil || !one.found {
+		t.Errorf("unexpected row: %v %v", one, err)
+	}
+}
+
+func TestOperator_WithHooksTargets(t *testing.T) {
+	useTestSqlite(t, openTestSqlite(t))
+	op := NewOperator(&testHooked{})
+
+	inTx := func(data interface{}) bool {
+		var ok bool
+		err := op.withHooks(context.Background(), data, hookBeforeInsert, hookAfterInsert, func(ctx context.Context) error {
+			_, ok = getExe(ctx).(*Tx)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	for _, data := range []interface{}{Params{"id": 1}, testHooked{ID: 1}, []Params{{"id": 1}}} {
+		if inTx(data) {
+			t.Errorf("unexpected tx for %T", data)
+		}
+	}
+	for _, data := range []interface{}{&testHooked{ID: 1}, []testHooked{{ID: 1}}} {
+		if !inTx(data) {
+			t.Errorf("no tx for %T", data)
+		}
+	}
+}
+
+type testAudited struct {
+	ID   int64  `db:"id,omitempty"`
+	Name string `db:"name"`
+}
+
+func (a *testAudited) TableName() string { return "audited" }
+
+func (a *testAudited) TableColumns() []string { return nil }
+
+// BeforeInsert writes an audit row, which must be rolled back with the insert.
+func (a *testAudited) BeforeInsert(ctx context.Context, exe Executor) error {
+	if _, err := exe.Execute(ctx, "INSERT INTO kv (k, v) VALUES (${k}, 1)", Params{"k": a.Name}); err != nil {
+		return err
+	}
+	if a.Name == "bad" {
+		return errors.New("bad name")
+	}
+	return nil
+}
+
+func (a *testAudited) AfterInsert(ctx context.Context, exe Executor) error {
+	if a.Name == "late" {
+		return errors.New("late error")
+	}
+	return nil
+}
+
+func (a *testAudited) BeforeUpdate(ctx context.Context, exe Executor) error {
+	if len(a.Name) < 1 {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+func TestOperator_HookErrorRollback(t *testing.T) {
+	db := openTestSqlite(t)
+	useTestSqlite(t, db)
+	ctx := context.Background()
+	if _, err := db.Execute(ctx, "CREATE TABLE audited (id INTEGER PRIMARY KEY, name TEXT)", nil); err != nil {
+		t.Fatal(err)
+	}
+	countAudited := func() int64 {
+		var n int64
+		if err := db.Get(ctx, "SELECT COUNT(*) FROM audited", nil, &n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	op := NewOperator(&testAudited{})
+	if _, err := op.Insert(ctx, &testAudited{Name: "bad"}, nil); err == nil || err.Error() != "bad name" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := op.Insert(ctx, &testAudited{Name: "late"}, nil); err == nil || err.Error() != "late error" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n, kv := countAudited(), countKV(t, db); n != 0 || kv != 0 {
+		t.Errorf("failed inserts are not rolled back: %d rows, %d audits", n, kv)
+	}
+
+	id, err := op.Insert(ctx, &testAudited{Name: "ok"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, kv := countAudited(), countKV(t, db); n != 1 || kv != 1 {
+		t.Errorf("unexpected counts: %d rows, %d audits", n, kv)
+	}
+
+	if _, err = op.Update(ctx, "id=${id}", nil, &testAudited{ID: id}, nil); err == nil || err.Error() != "empty name" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var name string
+	if err = db.Get(ctx, "SELECT name FROM audited WHERE id=${id}", Params{"id": id}, &name); err != nil || name != "ok" {
+		t.Errorf("failed update is written: %s %v", name, err)
+	}
+}
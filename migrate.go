@@ -0,0 +1,468 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MigrationTableName is the bookkeeping table of the applied migrations, TableNamePrefix applies too.
+var MigrationTableName = "sqlx_migrations"
+
+// MigrationLockTimeout is the timeout of waiting for the migration lock, and of releasing it.
+var MigrationLockTimeout = time.Minute
+
+// MigrationLockExpiry is the age of an abandoned sqlite migration lock, like the one of a crashed instance,
+// after which it is taken over. The lock is refreshed after each applied migration,
+// so a single migration running longer than it may be taken over while it is still running.
+var MigrationLockExpiry = 10 * time.Minute
+
+var ErrMigrationLocked = errors.New("sqlx: migration is locked by another instance")
+
+// Migration is a versioned schema change, written in Go or in SQL.
+// Up and Down take precedence over UpSQL and DownSQL, whose statements are executed in order and not bound.
+type Migration struct {
+	Version int64
+	Name    string
+	UpSQL   []string
+	DownSQL []string
+	Up      func(ctx context.Context, exe Executor) error
+	Down    func(ctx context.Context, exe Executor) error
+}
+
+type _MigrationRecord struct {
+	Version   int64  `db:"version,pk"`
+	Name      string `db:"name,size=255,notnull"`
+	AppliedAt int64  `db:"applied_at,notnull"`
+}
+
+func (r *_MigrationRecord) TableName() string { return MigrationTableName }
+
+func (r *_MigrationRecord) TableColumns() []string { return nil }
+
+// Migrator applies the registered migrations to a DB. Each migration runs in its own transaction,
+// mysql commits DDL implicitly, so a failed mysql migration may be applied partially.
+type Migrator struct {
+	db         *DB
+	migrations []*Migration
+	dryRun     io.Writer
+}
+
+func NewMigrator(db *DB) *Migrator { return &Migrator{db: db} }
+
+// SetDryRun makes Up and Down print the SQL to w instead of executing it, nil disables dry-run.
+func (m *Migrator) SetDryRun(w io.Writer) { m.dryRun = w }
+
+func (m *Migrator) Register(migrations ...*Migration) error {
+	for _, mg := range migrations {
+		for _, v := range m.migrations {
+			if v.Version == mg.Version {
+				return fmt.Errorf("sqlx: duplicate migration version %d", mg.Version)
+			}
+		}
+		m.migrations = append(m.migrations, mg)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+	return nil
+}
+
+func (m *Migrator) MustRegister(migrations ...*Migration) {
+	if err := m.Register(migrations...); err != nil {
+		panic(err)
+	}
+}
+
+func (m *Migrator) table() string { return TableNamePrefix + MigrationTableName }
+
+// pending returns the migrations not applied and not newer than the target, target < 1 means all.
+func (m *Migrator) pending(applied []int64, target int64) []*Migration {
+	done := make(map[int64]bool, len(applied))
+	for _, v := range applied {
+		done[v] = true
+	}
+	var lst []*Migration
+	for _, mg := range m.migrations {
+		if target > 0 && mg.Version > target {
+			break
+		}
+		if !done[mg.Version] {
+			lst = append(lst, mg)
+		}
+	}
+	return lst
+}
+
+// reverting returns the last `steps` applied migrations, newest first.
+func (m *Migrator) reverting(applied []int64, steps int) ([]*Migration, error) {
+	var lst []*Migration
+	for i := len(applied) - 1; i >= 0 && len(lst) < steps; i-- {
+		var found *Migration
+		for _, mg := range m.migrations {
+			if mg.Version == applied[i] {
+				found = mg
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("sqlx: applied migration %d is not registered", applied[i])
+		}
+		if found.Down == nil && len(found.DownSQL) < 1 {
+			return nil, fmt.Errorf("sqlx: migration %d is irreversible", found.Version)
+		}
+		lst = append(lst, found)
+	}
+	return lst, nil
+}
+
+func (m *Migrator) bind(query string, params interface{}) (string, []interface{}, error) {
+	return m.db.BindParams(query, params)
+}
+
+func (m *Migrator) lockName() string { return m.table() + "_lock" }
+
+const migrationLockRetryInterval = 200 * time.Millisecond
+
+// waitLock calls try until it takes the lock, or MigrationLockTimeout passes.
+func waitLock(ctx context.Context, try func() (bool, error)) error {
+	deadline := time.Now().Add(MigrationLockTimeout)
+	for {
+		ok, err := try()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrMigrationLocked
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockRetryInterval):
+		}
+	}
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	switch m.db.DriverType() {
+	case DriverTypePostgres:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(m.lockName()))
+		q, args, err := m.bind("SELECT pg_try_advisory_lock(${key})", Params{"key": int64(h.Sum64())})
+		if err != nil {
+			return err
+		}
+		return waitLock(ctx, func() (bool, error) {
+			var ok bool
+			err := conn.QueryRowContext(ctx, q, args...).Scan(&ok)
+			return ok, err
+		})
+	case DriverTypeMysql:
+		q, args, err := m.bind(
+			"SELECT GET_LOCK(${name}, ${timeout})",
+			Params{"name": m.lockName(), "timeout": int64(MigrationLockTimeout / time.Second)},
+		)
+		if err != nil {
+			return err
+		}
+		var ok sql.NullInt64
+		if err = conn.QueryRowContext(ctx, q, args...).Scan(&ok); err != nil {
+			return err
+		}
+		if ok.Int64 != 1 {
+			return ErrMigrationLocked
+		}
+		return nil
+	default: // sqlite has no session lock, a row of the lock table is the lock
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY, locked_at BIGINT NOT NULL)", m.lockName()))
+		if err != nil {
+			return err
+		}
+		return waitLock(ctx, func() (bool, error) {
+			now := NowFunc()
+			q, args, err := m.bind(
+				fmt.Sprintf("DELETE FROM %s WHERE id=1 AND locked_at<${expired}", m.lockName()),
+				Params{"expired": now.Add(-MigrationLockExpiry).Unix()},
+			)
+			if err != nil {
+				return false, err
+			}
+			if _, err = conn.ExecContext(ctx, q, args...); err != nil {
+				return false, err
+			}
+			// the row is inserted only if it does not exist, so the lock of another instance is not an error
+			q, args, err = m.bind(
+				fmt.Sprintf("INSERT OR IGNORE INTO %s (id, locked_at) VALUES (1, ${now})", m.lockName()),
+				Params{"now": now.Unix()},
+			)
+			if err != nil {
+				return false, err
+			}
+			r, err := conn.ExecContext(ctx, q, args...)
+			if err != nil {
+				return false, err
+			}
+			n, err := r.RowsAffected()
+			return n == 1, err
+		})
+	}
+}
+
+// refreshLock renews the sqlite lock, so it does not expire during a long run of migrations.
+func (m *Migrator) refreshLock(ctx context.Context, conn *sql.Conn) error {
+	switch m.db.DriverType() {
+	case DriverTypePostgres, DriverTypeMysql: // session locks do not expire
+		return nil
+	}
+	q, args, err := m.bind(
+		fmt.Sprintf("UPDATE %s SET locked_at=${now} WHERE id=1", m.lockName()),
+		Params{"now": NowFunc().Unix()},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, q, args...)
+	return err
+}
+
+// applyAll applies the migrations in order, and refreshes the lock after each of them.
+func (m *Migrator) applyAll(ctx context.Context, conn *sql.Conn, migrations []*Migration, up bool) error {
+	for _, mg := range migrations {
+		if err := m.apply(ctx, conn, mg, up); err != nil {
+			return err
+		}
+		if err := m.refreshLock(ctx, conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlock releases the lock even if the ctx of the migration is canceled.
+func (m *Migrator) unlock(conn *sql.Conn) error {
+	var q string
+	var params Params
+	switch m.db.DriverType() {
+	case DriverTypePostgres:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(m.lockName()))
+		q, params = "SELECT pg_advisory_unlock(${key})", Params{"key": int64(h.Sum64())}
+	case DriverTypeMysql:
+		q, params = "SELECT RELEASE_LOCK(${name})", Params{"name": m.lockName()}
+	default:
+		q = fmt.Sprintf("DELETE FROM %s WHERE id=1", m.lockName())
+	}
+	q, args, err := m.bind(q, params)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), MigrationLockTimeout)
+	defer cancel()
+	_, err = conn.ExecContext(ctx, q, args...)
+	return err
+}
+
+func (m *Migrator) sqlCreateTable() ([]string, error) {
+	return NewOperator(&_MigrationRecord{}).SqlCreateTable(m.db.DriverType())
+}
+
+func scanVersions(rows *sql.Rows) ([]int64, error) {
+	defer rows.Close()
+	var lst []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		lst = append(lst, v)
+	}
+	return lst, rows.Err()
+}
+
+// Applied returns the versions of the applied migrations, in ascending order.
+func (m *Migrator) Applied(ctx context.Context) ([]int64, error) {
+	rows, err := m.db.Raw().QueryContext(ctx, fmt.Sprintf("SELECT version FROM %s ORDER BY version", m.table()))
+	if err != nil {
+		return nil, err
+	}
+	return scanVersions(rows)
+}
+
+// appliedOrMissing returns the applied versions for dry-run, the bookkeeping table may not exist yet.
+func (m *Migrator) appliedOrMissing(ctx context.Context) (applied []int64, missing bool, err error) {
+	columns, err := liveColumns(ctx, m.db, m.table())
+	if err != nil {
+		return nil, false, err
+	}
+	if len(columns) < 1 {
+		return nil, true, nil
+	}
+	applied, err = m.Applied(ctx)
+	return applied, false, err
+}
+
+// run locks the migration, makes sure the bookkeeping table exists, then calls fn with the applied versions.
+// All the statements run in the same connection, which holds the lock.
+func (m *Migrator) run(ctx context.Context, fn func(conn *sql.Conn, applied []int64) error) (err error) {
+	conn, err := m.db.Raw().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err = m.lock(ctx, conn); err != nil {
+		return err
+	}
+	defer func() {
+		if e := m.unlock(conn); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	stmts, err := m.sqlCreateTable()
+	if err != nil {
+		return err
+	}
+	for _, q := range stmts {
+		if _, err = conn.ExecContext(ctx, q); err != nil {
+			return err
+		}
+	}
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT version FROM %s ORDER BY version", m.table()))
+	if err != nil {
+		return err
+	}
+	applied, err := scanVersions(rows)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+// apply runs one migration and its bookkeeping in a transaction of the connection.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mg *Migration, up bool) error {
+	std, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	tx := &Tx{std: std, db: m.db, ctx: ctx}
+	if m.db.logger != nil {
+		m.db.logger.Printf("migrate, version %d `%s`, up(%v)", mg.Version, mg.Name, up)
+	}
+
+	fn, stmts := mg.Up, mg.UpSQL
+	if !up {
+		fn, stmts = mg.Down, mg.DownSQL
+	}
+	if fn != nil {
+		err = fn(WithTx(ctx, tx), tx)
+	} else {
+		for _, q := range stmts {
+			if _, err = std.ExecContext(ctx, q); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		if up {
+			_, err = tx.Execute(
+				ctx,
+				fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (${version}, ${name}, ${applied_at})", m.table()),
+				Params{"version": mg.Version, "name": mg.Name, "applied_at": NowFunc().Unix()},
+			)
+		} else {
+			_, err = tx.Execute(ctx, fmt.Sprintf("DELETE FROM %s WHERE version=${version}", m.table()), Params{"version": mg.Version})
+		}
+	}
+	if err != nil {
+		_ = std.Rollback()
+		return fmt.Errorf("sqlx: migration %d `%s` failed, %w", mg.Version, mg.Name, err)
+	}
+	return std.Commit()
+}
+
+// printDryRun prints the SQL of the migrations, Go migrations are printed as comments.
+func (m *Migrator) printDryRun(migrations []*Migration, up bool, createTable bool) error {
+	var buf strings.Builder
+	if createTable {
+		stmts, err := m.sqlCreateTable()
+		if err != nil {
+			return err
+		}
+		for _, q := range stmts {
+			buf.WriteString(q)
+			buf.WriteString(";\n")
+		}
+	}
+	for _, mg := range migrations {
+		fn, stmts, direction := mg.Up, mg.UpSQL, "up"
+		if !up {
+			fn, stmts, direction = mg.Down, mg.DownSQL, "down"
+		}
+		buf.WriteString(fmt.Sprintf("-- migrate %s %d %s\n", direction, mg.Version, mg.Name))
+		if fn != nil {
+			buf.WriteString("-- go migration, the sql is not available\n")
+		} else {
+			for _, q := range stmts {
+				buf.WriteString(q)
+				buf.WriteString(";\n")
+			}
+		}
+		if up {
+			buf.WriteString(fmt.Sprintf(
+				"INSERT INTO %s (version, name, applied_at) VALUES (%d, '%s', %d);\n",
+				m.table(), mg.Version, strings.ReplaceAll(mg.Name, "'", "''"), NowFunc().Unix(),
+			))
+		} else {
+			buf.WriteString(fmt.Sprintf("DELETE FROM %s WHERE version=%d;\n", m.table(), mg.Version))
+		}
+	}
+	_, err := io.WriteString(m.dryRun, buf.String())
+	return err
+}
+
+// Up applies the pending migrations in ascending order.
+func (m *Migrator) Up(ctx context.Context) error { return m.UpTo(ctx, 0) }
+
+// UpTo applies the pending migrations not newer than the version.
+func (m *Migrator) UpTo(ctx context.Context, version int64) error {
+	if m.dryRun != nil {
+		applied, missing, err := m.appliedOrMissing(ctx)
+		if err != nil {
+			return err
+		}
+		return m.printDryRun(m.pending(applied, version), true, missing)
+	}
+	return m.run(ctx, func(conn *sql.Conn, applied []int64) error {
+		return m.applyAll(ctx, conn, m.pending(applied, version), true)
+	})
+}
+
+// Down reverts the last `steps` applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if m.dryRun != nil {
+		applied, _, err := m.appliedOrMissing(ctx)
+		if err != nil {
+			return err
+		}
+		lst, err := m.reverting(applied, steps)
+		if err != nil {
+			return err
+		}
+		return m.printDryRun(lst, false, false)
+	}
+	return m.run(ctx, func(conn *sql.Conn, applied []int64) error {
+		lst, err := m.reverting(applied, steps)
+		if err != nil {
+			return err
+		}
+		return m.applyAll(ctx, conn, lst, false)
+	})
+}
@@ -0,0 +1,258 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrator_Register(t *testing.T) {
+	noop := func(ctx context.Context, exe Executor) error { return nil }
+	m := NewMigrator(nil)
+	m.MustRegister(
+		&Migration{Version: 3, Name: "c", Up: noop},
+		&Migration{Version: 1, Name: "a", UpSQL: []string{"CREATE TABLE a (id INTEGER)"}, DownSQL: []string{"DROP TABLE a"}},
+		&Migration{Version: 2, Name: "b", Up: noop, Down: noop},
+	)
+	if err := m.Register(&Migration{Version: 2}); err == nil {
+		t.Errorf("duplicate version is registered")
+	}
+
+	versions := func(lst []*Migration) []int64 {
+		var vs []int64
+		for _, mg := range lst {
+			vs = append(vs, mg.Version)
+		}
+		return vs
+	}
+	if vs := versions(m.pending(nil, 0)); len(vs) != 3 || vs[0] != 1 || vs[2] != 3 {
+		t.Errorf("unexpected pending: %v", vs)
+	}
+	if vs := versions(m.pending([]int64{1}, 2)); len(vs) != 1 || vs[0] != 2 {
+		t.Errorf("unexpected pending: %v", vs)
+	}
+
+	lst, err := m.reverting([]int64{1, 2}, 5)
+	if vs := versions(lst); err != nil || len(vs) != 2 || vs[0] != 2 || vs[1] != 1 {
+		t.Errorf("unexpected reverting: %v %v", vs, err)
+	}
+	if _, err = m.reverting([]int64{1, 2, 3}, 1); err == nil {
+		t.Errorf("irreversible migration is reverted")
+	}
+	if _, err = m.reverting([]int64{4}, 1); err == nil {
+		t.Errorf("unknown migration is reverted")
+	}
+}
+
+func testMigrations() []*Migration {
+	return []*Migration{
+		{Version: 1, Name: "a", UpSQL: []string{"CREATE TABLE a (id INTEGER)"}, DownSQL: []string{"DROP TABLE a"}},
+		{
+			Version: 2,
+			Name:    "b",
+			Up: func(ctx context.Context, exe Executor) error {
+				_, err := exe.Execute(ctx, "INSERT INTO a (id) VALUES (${id})", Params{"id": 1})
+				return err
+			},
+			Down: func(ctx context.Context, exe Executor) error {
+				_, err := exe.Execute(ctx, "DELETE FROM a", nil)
+				return err
+			},
+		},
+	}
+}
+
+func appliedVersions(t *testing.T, m *Migrator) string {
+	applied, err := m.Applied(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprint(applied)
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+	m.MustRegister(testMigrations()...)
+
+	if err := m.UpTo(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if v := appliedVersions(t, m); v != "[1]" {
+		t.Errorf("unexpected applied: %s", v)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if err := db.Get(ctx, "SELECT COUNT(*) FROM a", nil, &n); err != nil || n != 1 {
+		t.Errorf("unexpected rows of a: %d %v", n, err)
+	}
+	if v := appliedVersions(t, m); v != "[1 2]" {
+		t.Errorf("unexpected applied: %s", v)
+	}
+
+	if err := m.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Get(ctx, "SELECT COUNT(*) FROM a", nil, &n); err != nil || n != 0 {
+		t.Errorf("unexpected rows of a: %d %v", n, err)
+	}
+	if err := m.Down(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	if v := appliedVersions(t, m); v != "[]" {
+		t.Errorf("unexpected applied: %s", v)
+	}
+	if err := db.Get(ctx, "SELECT COUNT(*) FROM a", nil, &n); err == nil {
+		t.Errorf("table a is not dropped")
+	}
+}
+
+func TestMigrator_FailedMigration(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+	m.MustRegister(testMigrations()[0], &Migration{
+		Version: 2,
+		Name:    "bad",
+		UpSQL:   []string{"CREATE TABLE b (id INTEGER)", "INSERT INTO missing (id) VALUES (1)"},
+	})
+
+	if err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "migration 2 `bad` failed") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if v := appliedVersions(t, m); v != "[1]" {
+		t.Errorf("unexpected applied: %s", v)
+	}
+	var n int64
+	if err := db.Get(ctx, "SELECT COUNT(*) FROM b", nil, &n); err == nil {
+		t.Errorf("failed migration is not rolled back")
+	}
+	// the lock is released
+	if err := m.Down(ctx, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+	m.MustRegister(testMigrations()...)
+
+	var buf strings.Builder
+	m.SetDryRun(&buf)
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, v := range []string{
+		"CREATE TABLE IF NOT EXISTS sqlx_migrations",
+		"-- migrate up 1 a\nCREATE TABLE a (id INTEGER);\nINSERT INTO sqlx_migrations",
+		"-- migrate up 2 b\n-- go migration",
+	} {
+		if !strings.Contains(out, v) {
+			t.Errorf("missing `%s` in dry-run output:\n%s", v, out)
+		}
+	}
+	if columns, err := liveColumns(ctx, db, m.table()); err != nil || len(columns) > 0 {
+		t.Errorf("dry-run creates the table: %v", err)
+	}
+
+	m.SetDryRun(nil)
+	if err := m.UpTo(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	m.SetDryRun(&buf)
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if out = buf.String(); strings.Contains(out, "CREATE TABLE") || !strings.HasPrefix(out, "-- migrate up 2 b") {
+		t.Errorf("unexpected dry-run output:\n%s", out)
+	}
+	buf.Reset()
+	if err := m.Down(ctx, 1); err != nil || buf.String() != "-- migrate down 1 a\nDROP TABLE a;\nDELETE FROM sqlx_migrations WHERE version=1;\n" {
+		t.Errorf("unexpected dry-run output: %v\n%s", err, buf.String())
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := m.Up(canceled); err == nil {
+		t.Errorf("dry-run ignores the error of reading the applied migrations")
+	}
+}
+
+func TestMigrator_SqliteLock(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+	m.MustRegister(testMigrations()[0])
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	lock := func(at time.Time) {
+		if _, err := db.Execute(ctx, "INSERT INTO sqlx_migrations_lock (id, locked_at) VALUES (1, ${at})", Params{"at": at.Unix()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prev := MigrationLockTimeout
+	MigrationLockTimeout = 300 * time.Millisecond
+	defer func() { MigrationLockTimeout = prev }()
+	lock(time.Now())
+	begin := time.Now()
+	if err := m.Down(ctx, 1); !errors.Is(err, ErrMigrationLocked) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed < MigrationLockTimeout {
+		t.Errorf("the lock is not waited for, %s", elapsed)
+	}
+
+	if _, err := db.Execute(ctx, "DELETE FROM sqlx_migrations_lock", nil); err != nil {
+		t.Fatal(err)
+	}
+	lock(time.Now().Add(-MigrationLockExpiry - time.Minute))
+	if err := m.Down(ctx, 1); err != nil {
+		t.Errorf("expired lock is not taken over: %v", err)
+	}
+	var n int64
+	if err := db.Get(ctx, "SELECT COUNT(*) FROM sqlx_migrations_lock", nil, &n); err != nil || n != 0 {
+		t.Errorf("lock is not released: %d %v", n, err)
+	}
+}
+
+func TestMigrator_SqliteLockRefresh(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev := NowFunc
+	NowFunc = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	defer func() { NowFunc = prev }()
+
+	var lockedAt []int64
+	readLock := func(ctx context.Context, exe Executor) error {
+		var v int64
+		if err := exe.Get(ctx, "SELECT locked_at FROM sqlx_migrations_lock WHERE id=1", nil, &v); err != nil {
+			return err
+		}
+		lockedAt = append(lockedAt, v)
+		return nil
+	}
+	m := NewMigrator(db)
+	m.MustRegister(&Migration{Version: 1, Name: "a", Up: readLock}, &Migration{Version: 2, Name: "b", Up: readLock})
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(lockedAt) != 2 || lockedAt[1] <= lockedAt[0] {
+		t.Errorf("the lock is not refreshed: %v", lockedAt)
+	}
+}
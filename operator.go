@@ -17,11 +17,27 @@ type Model interface {
 	TableColumns() []string
 }
 
+type ImmutablePolicy int
+
+const (
+	// ImmutableReject makes Update return an *ImmutableColumnsError
+	ImmutableReject = ImmutablePolicy(iota)
+	// ImmutableDrop makes Update silently drop the immutable columns
+	ImmutableDrop
+)
+
 type Operator struct {
-	smap       *reflectx.StructMap
-	groups     map[string]map[string]int
-	immutables map[string]bool
-	model      Model
+	smap            *reflectx.StructMap
+	groups          map[string]map[string]int
+	immutables      map[string]bool
+	immutablePolicy ImmutablePolicy
+	pk              string
+	softDelete      string
+	version         string
+	autoCreates     []string
+	autoUpdates     []string
+	hooks           _Hook
+	model           Model
 }
 
 func (op *Operator) addGroup(group, column string, ind int) {
@@ -43,10 +59,11 @@ func NewOperator(model Model) *Operator {
 		model:      model,
 		groups:     map[string]map[string]int{},
 		immutables: map[string]bool{},
+		hooks:      detectHooks(t),
 	}
 
-	idx := 0
-	for n, f := range op.smap.Names {
+	for idx, n := range orderedNames(op.smap) {
+		f := op.smap.Names[n]
 		groups := f.Options["group"]
 		groups += "|*"
 		for _, gn := range strings.Split(groups, "|") {
@@ -59,11 +76,60 @@ func NewOperator(model Model) *Operator {
 		if _, ok := f.Options["immutable"]; ok {
 			op.immutables[n] = true
 		}
-		idx++
+		if _, ok := f.Options["pk"]; ok {
+			op.pk = n
+		}
+		if _, ok := f.Options["softdelete"]; ok {
+			op.softDelete = n
+		}
+		if _, ok := f.Options["version"]; ok {
+			op.version = n
+		}
+		if _, ok := f.Options["autoCreateTime"]; ok {
+			op.autoCreates = append(op.autoCreates, n)
+		}
+		if _, ok := f.Options["autoUpdateTime"]; ok {
+			op.autoUpdates = append(op.autoUpdates, n)
+		}
+	}
+	if len(op.pk) < 1 && op.smap.Names["id"] != nil {
+		op.pk = "id"
 	}
 	return op
 }
 
+// isNestedField reports whether the field is under a non-embedded struct field, like `nick.Valid` of a sql.NullString.
+// Such a field is not a column, its parent is.
+func isNestedField(f *reflectx.FieldInfo) bool {
+	for p := f.Parent; p != nil && p.Parent != nil; p = p.Parent {
+		if !p.Embedded {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedNames returns the column names by the declaration order of the fields, embedded fields in place.
+func orderedNames(smap *reflectx.StructMap) []string {
+	lst := make([]string, 0, len(smap.Names))
+	for n, f := range smap.Names {
+		if isNestedField(f) {
+			continue
+		}
+		lst = append(lst, n)
+	}
+	sort.Slice(lst, func(i, j int) bool {
+		a, b := smap.Names[lst[i]].Index, smap.Names[lst[j]].Index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return lst
+}
+
 func (op *Operator) Group(group string, order bool) []string {
 	gm := op.groups[group]
 	if len(gm) < 1 {
@@ -96,21 +162,44 @@ func (op *Operator) IsImmutable(name string) bool {
 	return op.immutables[name]
 }
 
-var TableNamePrefix = ""
+func (op *Operator) SetImmutablePolicy(v ImmutablePolicy) { op.immutablePolicy = v }
 
-func (op *Operator) CreateTable(ctx context.Context) error {
-	var buf strings.Builder
-	buf.WriteString("CREATE TABLE IF NOT EXISTS ")
-	buf.WriteString(TableNamePrefix)
-	buf.WriteString(op.model.TableName())
-	buf.WriteString(" (")
-	buf.WriteString(strings.Join(op.model.TableColumns(), ", "))
-	buf.WriteString(")")
+type ImmutableColumnsError struct {
+	Columns []string
+}
+
+func (e *ImmutableColumnsError) Error() string {
+	return fmt.Sprintf("sqlx: can not update immutable columns `%s`", strings.Join(e.Columns, ","))
+}
 
-	_, e := wDB.Execute(ctx, buf.String(), nil)
-	return e
+// AllowImmutable makes Update write immutable columns, for migrations and admin tools.
+func AllowImmutable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, _KeyAllowImmutable, true)
 }
 
+// updateColumns returns the columns can be updated, according to the immutable policy.
+func (op *Operator) updateColumns(ctx context.Context, columns []string) ([]string, error) {
+	if len(op.immutables) < 1 || ctx.Value(_KeyAllowImmutable) != nil {
+		return columns, nil
+	}
+
+	var immutables []string
+	lst := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if op.immutables[c] {
+			immutables = append(immutables, c)
+			continue
+		}
+		lst = append(lst, c)
+	}
+	if len(immutables) > 0 && op.immutablePolicy == ImmutableReject {
+		return nil, &ImmutableColumnsError{Columns: immutables}
+	}
+	return lst, nil
+}
+
+var TableNamePrefix = ""
+
 var ErrEmptyData = errors.New("sqlx: empty data")
 var ErrEmptyCondition = errors.New("sqlx: empty condition")
 
@@ -143,7 +232,25 @@ func (op *Operator) Get(
 	dist interface{},
 ) error {
 	ctx, exe := PickExecutor(ctx)
-	return exe.Get(ctx, op.SqlSelect(groupOrKeys, condition), params, dist)
+	q := op.SqlSelect(groupOrKeys, op.scope(ctx, condition))
+	if op.hooks&hookAfterFind == 0 {
+		return exe.Get(ctx, q, params, dist)
+	}
+
+	rows, err := exe.Rows(ctx, q, params)
+	if err != nil {
+		return err
+	}
+	if !rows.Next() {
+		_ = rows.Close()
+		return rows.Err()
+	}
+	err = rows.Scan(dist)
+	_ = rows.Close()
+	if err != nil {
+		return err
+	}
+	return op.afterFind(ctx, exe, dist, 0)
 }
 
 func (op *Operator) Select(
@@ -152,12 +259,19 @@ func (op *Operator) Select(
 	dist interface{},
 ) error {
 	_, exe := PickExecutor(ctx)
-	return exe.Select(ctx, op.SqlSelect(groupOrKeys, condition), params, dist)
+	begin := sliceLen(dist)
+	if err := exe.Select(ctx, op.SqlSelect(groupOrKeys, op.scope(ctx, condition)), params, dist); err != nil {
+		return err
+	}
+	return op.afterFind(ctx, exe, dist, begin)
 }
 
 type Returning struct {
 	Keys  []string
 	Dists DirectDists
+	// Rows is a slice pointer, the returned rows of multi-row writes are appended to it.
+	// It takes precedence over Dists.
+	Rows interface{}
 }
 
 func (op *Operator) SqlInsert(columns []string, returning *Returning) string {
@@ -198,21 +312,70 @@ func (op *Operator) SqlInsert(columns []string, returning *Returning) string {
 	return buf.String()
 }
 
+// writeData converts the data of INSERT or UPDATE to a map.
+// Zero fields tagged `omitempty` are skipped, and zero fields tagged `default` or `autoincrement` are skipped in INSERT,
+// so the database default or sequence applies.
+func writeData(data interface{}, insert bool) (Params, error) {
+	return paramsToMapWith(data, func(f *reflectx.FieldInfo, v reflect.Value) bool {
+		if isNestedField(f) {
+			return true
+		}
+		if _, ok := f.Options["omitempty"]; ok && v.IsZero() {
+			return true
+		}
+		if _, ok := f.Options["default"]; ok && insert && v.IsZero() {
+			return true
+		}
+		if _, ok := f.Options["autoincrement"]; ok && insert && v.IsZero() {
+			return true
+		}
+		return false
+	})
+}
+
+// Insert inserts a row. The insert hooks are called if params is a model pointer.
 func (op *Operator) Insert(ctx context.Context, params interface{}, returning *Returning) (int64, error) {
-	pm, err := paramsToMap(params)
+	var n int64
+	err := op.withHooks(ctx, params, hookBeforeInsert, hookAfterInsert, func(ctx context.Context) error {
+		var err error
+		n, err = op.insert(ctx, params, returning)
+		return err
+	})
+	return n, err
+}
+
+func (op *Operator) insert(ctx context.Context, params interface{}, returning *Returning) (int64, error) {
+	pm, err := writeData(params, true)
 	if err != nil {
 		return 0, err
 	}
+	pm = op.fillTimestamps(pm, true)
+	keys := pm.Keys()
 
-	_, exe := PickExecutor(ctx)
-	if returning == nil {
-		r, e := exe.Execute(ctx, op.SqlInsert(pm.Keys(), returning), pm)
-		if e != nil {
+	ctx, exe := PickExecutor(ctx)
+	d := exe.DriverType()
+	if returning != nil {
+		if !d.SupportsReturning() {
+			return 0, op.insertEmulated(ctx, exe, keys, pm, returning)
+		}
+		_, err = op.scanReturning(ctx, exe, op.SqlInsert(keys, returning), pm, returning)
+		return 0, err
+	}
+
+	if d == DriverTypePostgres { // postgres does not support LastInsertId
+		if !op.isIntPK() {
+			_, e := exe.Execute(ctx, op.SqlInsert(keys, nil), pm)
 			return 0, e
 		}
-		return r.LastInsertId()
+		var id int64
+		e := exe.GetDirect(ctx, op.SqlInsert(keys, &Returning{Keys: []string{op.pk}}), pm, DirectDists{&id})
+		return id, e
+	}
+	r, e := exe.Execute(ctx, op.SqlInsert(keys, nil), pm)
+	if e != nil {
+		return 0, e
 	}
-	return 0, exe.GetDirect(ctx, op.SqlInsert(pm.Keys(), returning), pm, returning.Dists)
+	return r.LastInsertId()
 }
 
 func (op *Operator) SqlUpdate(condition string, columns []string, returning *Returning) string {
@@ -223,6 +386,7 @@ func (op *Operator) SqlUpdate(condition string, columns []string, returning *Ret
 		panic(ErrEmptyCondition)
 	}
 
+	versioned := op.isVersioned(columns)
 	var buf strings.Builder
 	buf.WriteString("UPDATE ")
 	buf.WriteString(op.model.TableName())
@@ -231,16 +395,24 @@ func (op *Operator) SqlUpdate(condition string, columns []string, returning *Ret
 	ind := 0
 	for _, k := range columns {
 		buf.WriteString(k)
-		buf.WriteString("=${")
-		buf.WriteString(k)
-		buf.WriteByte('}')
+		if versioned && k == op.version {
+			buf.WriteString("=" + k + "+1")
+		} else {
+			buf.WriteString("=${")
+			buf.WriteString(k)
+			buf.WriteByte('}')
+		}
 		if ind < end {
 			buf.WriteByte(',')
 		}
 		ind++
 	}
 	buf.WriteString(" WHERE ")
-	buf.WriteString(condition)
+	if versioned {
+		buf.WriteString(op.versionCondition(condition))
+	} else {
+		buf.WriteString(condition)
+	}
 	if returning != nil {
 		buf.WriteString(" RETURNING ")
 		buf.WriteString(strings.Join(returning.Keys, ","))
@@ -248,27 +420,71 @@ func (op *Operator) SqlUpdate(condition string, columns []string, returning *Ret
 	return buf.String()
 }
 
+// Update updates the rows matched by the condition. The update hooks are called if data is a model pointer,
+// a Params or struct value data updates without hooks.
+// The data of a versioned model must carry the version, or ErrMissingVersion is returned, see Unversioned.
 func (op *Operator) Update(ctx context.Context, condition string, params, data interface{}, returning *Returning) (int64, error) {
-	pm, err := paramsToMap(params)
+	var n int64
+	err := op.withHooks(ctx, data, hookBeforeUpdate, hookAfterUpdate, func(ctx context.Context) error {
+		var err error
+		n, err = op.update(ctx, condition, params, data, returning)
+		return err
+	})
+	return n, err
+}
+
+func (op *Operator) update(ctx context.Context, condition string, params, data interface{}, returning *Returning) (int64, error) {
+	dm, err := writeData(data, false)
 	if err != nil {
 		return 0, err
 	}
-	dm, err := paramsToMap(data)
+	dm = op.fillTimestamps(dm, false)
+	columns, err := op.updateColumns(ctx, dm.Keys())
 	if err != nil {
 		return 0, err
 	}
-	for k, v := range dm {
-		pm[k] = v
+	if len(columns) < 1 {
+		return 0, ErrEmptyData
 	}
-	_, exe := PickExecutor(ctx)
-	if returning == nil {
-		r, e := exe.Execute(ctx, op.SqlUpdate(condition, dm.Keys(), returning), pm)
+	pm := Merge(dm, params)
+	versioned := op.isVersioned(columns)
+	if !versioned && len(op.version) > 0 && ctx.Value(_KeyUnversioned) == nil {
+		return 0, ErrMissingVersion
+	}
+	if versioned {
+		pm = Merge(Params{oldVersionParam: dm[op.version]}, dm, params)
+	}
+	condition = op.scope(ctx, condition)
+	ctx, exe := PickExecutor(ctx)
+
+	var n int64
+	switch {
+	case returning == nil:
+		r, e := exe.Execute(ctx, op.SqlUpdate(condition, columns, returning), pm)
 		if e != nil {
 			return 0, e
 		}
-		return r.RowsAffected()
+		if n, err = r.RowsAffected(); err != nil {
+			return 0, err
+		}
+	case !exe.DriverType().SupportsReturning():
+		n, err = op.updateEmulated(ctx, exe, condition, columns, pm, returning)
+	default:
+		n, err = op.scanReturning(ctx, exe, op.SqlUpdate(condition, columns, returning), pm, returning)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if versioned {
+		if n < 1 {
+			return 0, ErrStaleObject
+		}
+		op.bumpVersion(data)
+	}
+	if returning != nil {
+		return 0, nil
 	}
-	return 0, exe.GetDirect(ctx, op.SqlUpdate(condition, dm.Keys(), returning), pm, returning.Dists)
+	return n, nil
 }
 
 func (op *Operator) SqlDelete(condition string) string {
@@ -284,7 +500,34 @@ func (op *Operator) SqlDelete(condition string) string {
 	return buf.String()
 }
 
+// Delete deletes rows, or marks them as deleted if the model has a `softdelete` column.
+// The delete hooks are called if params is a model pointer.
 func (op *Operator) Delete(ctx context.Context, condition string, params interface{}) (int64, error) {
+	if len(op.softDelete) < 1 {
+		return op.HardDelete(ctx, condition, params)
+	}
+	var n int64
+	err := op.withHooks(ctx, params, hookBeforeDelete, hookAfterDelete, func(ctx context.Context) error {
+		var err error
+		n, err = op.softDeleteRows(ctx, condition, params)
+		return err
+	})
+	return n, err
+}
+
+// HardDelete deletes rows, even if the model has a `softdelete` column.
+// The delete hooks are called if params is a model pointer.
+func (op *Operator) HardDelete(ctx context.Context, condition string, params interface{}) (int64, error) {
+	var n int64
+	err := op.withHooks(ctx, params, hookBeforeDelete, hookAfterDelete, func(ctx context.Context) error {
+		var err error
+		n, err = op.hardDelete(ctx, condition, params)
+		return err
+	})
+	return n, err
+}
+
+func (op *Operator) hardDelete(ctx context.Context, condition string, params interface{}) (int64, error) {
 	_, exe := PickExecutor(ctx)
 	r, e := exe.Execute(ctx, op.SqlDelete(condition), params)
 	if e != nil {
@@ -0,0 +1,112 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testBase struct {
+	ID        int64     `db:"id,omitempty,immutable"`
+	CreatedAt time.Time `db:"created_at,default,immutable"`
+}
+
+type testUser struct {
+	testBase
+	Name     string  `db:"name"`
+	Nickname *string `db:"nickname,omitempty"`
+	Age      int     `db:"age,omitempty"`
+}
+
+func (u *testUser) TableName() string { return "user" }
+
+func (u *testUser) TableColumns() []string { return nil }
+
+func TestOperator_WriteData(t *testing.T) {
+	op := NewOperator(&testUser{})
+	nickname := "z"
+	cases := []struct {
+		data   interface{}
+		insert string
+		update string
+	}{
+		{
+			&testUser{Name: "ztk"},
+			"INSERT INTO user(name) VALUES(${name})",
+			"UPDATE user SET created_at=${created_at},name=${name} WHERE id=${id}",
+		},
+		{
+			testUser{testBase: testBase{ID: 3, CreatedAt: time.Now()}, Name: "ztk", Nickname: &nickname, Age: 12},
+			"INSERT INTO user(age,created_at,id,name,nickname) VALUES(${age},${created_at},${id},${name},${nickname})",
+			"UPDATE user SET age=${age},created_at=${created_at},id=${id},name=${name},nickname=${nickname} WHERE id=${id}",
+		},
+		{
+			Params{"name": "ztk", "age": 0},
+			"INSERT INTO user(age,name) VALUES(${age},${name})",
+			"UPDATE user SET age=${age},name=${name} WHERE id=${id}",
+		},
+	}
+	for _, c := range cases {
+		m, err := writeData(c.data, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q := op.SqlInsert(m.Keys(), nil); q != c.insert {
+			t.Errorf("unexpected insert: %s", q)
+		}
+		m, err = writeData(c.data, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q := op.SqlUpdate("id=${id}", m.Keys(), nil); q != c.update {
+			t.Errorf("unexpected update: %s", q)
+		}
+	}
+}
+
+func TestOperator_UpdateColumns(t *testing.T) {
+	op := NewOperator(&testUser{})
+	ctx := context.Background()
+	columns := []string{"created_at", "id", "name"}
+
+	_, err := op.updateColumns(ctx, columns)
+	var ice *ImmutableColumnsError
+	if !errors.As(err, &ice) || fmt.Sprint(ice.Columns) != "[created_at id]" {
+		t.Errorf("expected ImmutableColumnsError, got %v", err)
+	}
+	if lst, err := op.updateColumns(AllowImmutable(ctx), columns); err != nil || len(lst) != 3 {
+		t.Errorf("unexpected columns: %v %v", lst, err)
+	}
+	op.SetImmutablePolicy(ImmutableDrop)
+	if lst, err := op.updateColumns(ctx, columns); err != nil || fmt.Sprint(lst) != "[name]" {
+		t.Errorf("unexpected columns: %v %v", lst, err)
+	}
+}
+
+func TestOperator_SqlAggregate(t *testing.T) {
+	op := NewOperator(&testUser{})
+	if q := op.SqlAggregate(AggregateCount, "*", "age>${age}"); q != "SELECT COUNT(*) FROM user WHERE age>${age}" {
+		t.Errorf("unexpected query: %s", q)
+	}
+	if q := op.SqlExists("age>${age}"); q != "SELECT EXISTS(SELECT 1 FROM user WHERE age>${age})" {
+		t.Errorf("unexpected query: %s", q)
+	}
+	if err := op.Sum(context.Background(), "agee", "1=1", nil, nil); err == nil {
+		t.Errorf("expected unknown column error")
+	}
+}
+
+func TestOperator_PrimaryKey(t *testing.T) {
+	op := NewOperator(&testUser{})
+	if op.PrimaryKey() != "id" || !op.isIntPK() {
+		t.Errorf("unexpected primary key: %s", op.PrimaryKey())
+	}
+	if q := op.sqlSelectByPK([]string{"id", "name"}, true); q != "SELECT id,name FROM user WHERE id IN (${__pk})" {
+		t.Errorf("unexpected query: %s", q)
+	}
+	if DriverTypeMysql.SupportsReturning() || !DriverTypePostgres.SupportsReturning() {
+		t.Errorf("unexpected returning support")
+	}
+}
@@ -0,0 +1,261 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// Page is the ordering and paging of Operator.SelectPage.
+type Page struct {
+	OrderBy []Order
+	// Limit less than 1 means no limit
+	Limit  int64
+	Offset int64
+	// Cursor is the token returned by the previous SelectPage, which enables keyset pagination.
+	// The last column of OrderBy should be unique, so that the order is total.
+	// The order columns should be NOT NULL, and of integer, float, string, bool, time or bytes types.
+	Cursor string
+}
+
+// KeysetParamPrefix is the prefix of the param names generated by keyset pagination.
+const KeysetParamPrefix = "__k"
+
+var ErrBadCursor = errors.New("sqlx: bad page cursor")
+
+// _CursorValue keeps the kind of a cursor value, so it is bound as the same type as the order column.
+type _CursorValue struct {
+	Kind  byte   `json:"k"`
+	Value string `json:"v"`
+}
+
+func toCursorValue(v interface{}) (_CursorValue, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			break
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || ((rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil()) {
+		return _CursorValue{}, errors.New("sqlx: NULL can not be a page cursor value")
+	}
+
+	v = rv.Interface()
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return _CursorValue{}, err
+		}
+		if dv == nil {
+			return _CursorValue{}, errors.New("sqlx: NULL can not be a page cursor value")
+		}
+		v = dv
+		rv = reflect.ValueOf(dv)
+	}
+
+	switch tv := v.(type) {
+	case time.Time:
+		return _CursorValue{Kind: 't', Value: tv.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return _CursorValue{Kind: 'x', Value: base64.StdEncoding.EncodeToString(tv)}, nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return _CursorValue{Kind: 'i', Value: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return _CursorValue{Kind: 'u', Value: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return _CursorValue{Kind: 'f', Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.String:
+		return _CursorValue{Kind: 's', Value: rv.String()}, nil
+	case reflect.Bool:
+		return _CursorValue{Kind: 'b', Value: strconv.FormatBool(rv.Bool())}, nil
+	}
+	return _CursorValue{}, fmt.Errorf("sqlx: can not encode `%T` to a page cursor", v)
+}
+
+func (cv _CursorValue) value() (interface{}, error) {
+	switch cv.Kind {
+	case 'i':
+		return strconv.ParseInt(cv.Value, 10, 64)
+	case 'u':
+		return strconv.ParseUint(cv.Value, 10, 64)
+	case 'f':
+		return strconv.ParseFloat(cv.Value, 64)
+	case 's':
+		return cv.Value, nil
+	case 'b':
+		return strconv.ParseBool(cv.Value)
+	case 't':
+		return time.Parse(time.RFC3339Nano, cv.Value)
+	case 'x':
+		return base64.StdEncoding.DecodeString(cv.Value)
+	}
+	return nil, ErrBadCursor
+}
+
+// encodeCursor encodes the values of the order columns.
+func encodeCursor(columns []string, values []interface{}) (string, error) {
+	lst := make([]_CursorValue, 0, len(values))
+	for i, v := range values {
+		cv, err := toCursorValue(v)
+		if err != nil {
+			return "", fmt.Errorf("%w, order column `%s`", err, columns[i])
+		}
+		lst = append(lst, cv)
+	}
+	v, err := json.Marshal(lst)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(v), nil
+}
+
+func decodeCursor(cursor string, size int) ([]interface{}, error) {
+	v, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var lst []_CursorValue
+	if err = json.Unmarshal(v, &lst); err != nil || len(lst) != size {
+		return nil, ErrBadCursor
+	}
+	values := make([]interface{}, 0, size)
+	for _, cv := range lst {
+		value, err := cv.value()
+		if err != nil {
+			return nil, ErrBadCursor
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// SqlSelectPage renders SqlSelect with ORDER BY, LIMIT and OFFSET for the driver.
+// The returned params contain the values of the cursor, which should be merged with the params of the condition.
+func (op *Operator) SqlSelectPage(d DriverType, groupOrKeys string, condition string, page *Page) (string, Params, error) {
+	if len(condition) < 1 {
+		return "", nil, ErrEmptyCondition
+	}
+	if page == nil {
+		page = &Page{}
+	}
+	for _, o := range page.OrderBy {
+		if _, ok := op.smap.Names[o.Column]; !ok {
+			return "", nil, fmt.Errorf("sqlx: unknown order column `%s`", o.Column)
+		}
+	}
+
+	var params Params
+	if len(page.Cursor) > 0 {
+		if len(page.OrderBy) < 1 {
+			return "", nil, fmt.Errorf("sqlx: keyset pagination requires order columns")
+		}
+		values, err := decodeCursor(page.Cursor, len(page.OrderBy))
+		if err != nil {
+			return "", nil, err
+		}
+
+		// (a > ${__k0}) OR (a = ${__k0} AND b > ${__k1}) ...
+		params = Params{}
+		var buf strings.Builder
+		buf.WriteString("(")
+		buf.WriteString(condition)
+		buf.WriteString(") AND (")
+		for i, o := range page.OrderBy {
+			params[KeysetParamPrefix+strconv.Itoa(i)] = values[i]
+			if i > 0 {
+				buf.WriteString(" OR ")
+			}
+			buf.WriteByte('(')
+			for j := 0; j < i; j++ {
+				buf.WriteString(fmt.Sprintf("%s = ${%s%d} AND ", page.OrderBy[j].Column, KeysetParamPrefix, j))
+			}
+			cmp := ">"
+			if o.Desc {
+				cmp = "<"
+			}
+			buf.WriteString(fmt.Sprintf("%s %s ${%s%d}", o.Column, cmp, KeysetParamPrefix, i))
+			buf.WriteByte(')')
+		}
+		buf.WriteString(")")
+		condition = buf.String()
+	}
+
+	var buf strings.Builder
+	buf.WriteString(op.SqlSelect(groupOrKeys, condition))
+	if len(page.OrderBy) > 0 {
+		buf.WriteString(" ORDER BY ")
+		for i, o := range page.OrderBy {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(o.Column)
+			if o.Desc {
+				buf.WriteString(" DESC")
+			}
+		}
+	}
+	buf.WriteString(d.LimitClause(page.Limit, page.Offset))
+	return buf.String(), params, nil
+}
+
+// SelectPage fetches one page of rows, dist must be a slice pointer.
+// If the page is full and ordered, it returns the cursor of the next page, otherwise an empty string.
+// The rows are appended to dist, and only the appended rows make the page and its cursor.
+func (op *Operator) SelectPage(
+	ctx context.Context, groupOrKeys string, condition string,
+	params interface{},
+	page *Page,
+	dist interface{},
+) (string, error) {
+	_, exe := PickExecutor(ctx)
+	q, cursorParams, err := op.SqlSelectPage(exe.DriverType(), groupOrKeys, op.scope(ctx, condition), page)
+	if err != nil {
+		return "", err
+	}
+	if cursorParams != nil {
+		params = Merge(cursorParams, params)
+	}
+	begin := sliceLen(dist)
+	if err = exe.Select(ctx, q, params, dist); err != nil {
+		return "", err
+	}
+	if err = op.afterFind(ctx, exe, dist, begin); err != nil {
+		return "", err
+	}
+
+	if page == nil || page.Limit < 1 || len(page.OrderBy) < 1 {
+		return "", nil
+	}
+	sliceV := reflect.ValueOf(dist).Elem()
+	appended := sliceV.Len() - begin
+	if appended < 1 || int64(appended) < page.Limit {
+		return "", nil
+	}
+	last := sliceV.Index(sliceV.Len() - 1)
+	columns := make([]string, 0, len(page.OrderBy))
+	values := make([]interface{}, 0, len(page.OrderBy))
+	for _, o := range page.OrderBy {
+		v, err := lookupPath(last, o.Column)
+		if err != nil {
+			return "", fmt.Errorf("sqlx: order column `%s` is not selected, %w", o.Column, err)
+		}
+		columns = append(columns, o.Column)
+		values = append(values, v)
+	}
+	return encodeCursor(columns, values)
+}
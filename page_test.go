@@ -0,0 +1,127 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOperator_SqlSelectPage(t *testing.T) {
+	op := NewOperator(&testUser{})
+	order := []Order{{Column: "age", Desc: true}, {Column: "id"}}
+
+	cases := []struct {
+		d     DriverType
+		page  *Page
+		query string
+	}{
+		{DriverTypePostgres, &Page{OrderBy: order, Limit: 10, Offset: 20}, "SELECT * FROM user WHERE name=${name} ORDER BY age DESC,id LIMIT 10 OFFSET 20"},
+		{DriverTypeMysql, &Page{OrderBy: order, Limit: 10, Offset: 20}, "SELECT * FROM user WHERE name=${name} ORDER BY age DESC,id LIMIT 20, 10"},
+		{DriverTypeMysql, &Page{Offset: 20}, "SELECT * FROM user WHERE name=${name} LIMIT 20, 18446744073709551615"},
+		{DriverTypeSqlite3, &Page{Offset: 20}, "SELECT * FROM user WHERE name=${name} LIMIT -1 OFFSET 20"},
+		{DriverTypeSqlite3, &Page{Limit: 5}, "SELECT * FROM user WHERE name=${name} LIMIT 5"},
+		{DriverTypePostgres, nil, "SELECT * FROM user WHERE name=${name}"},
+	}
+	for _, c := range cases {
+		q, _, err := op.SqlSelectPage(c.d, "*", "name=${name}", c.page)
+		if err != nil || q != c.query {
+			t.Errorf("unexpected query: %s %v", q, err)
+		}
+	}
+
+	if _, _, err := op.SqlSelectPage(DriverTypePostgres, "*", "1=1", &Page{OrderBy: []Order{{Column: "agee"}}}); err == nil {
+		t.Errorf("expected unknown column error")
+	}
+
+	cursor, err := encodeCursor([]string{"age", "id"}, []interface{}{12, int64(1) << 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, params, err := op.SqlSelectPage(DriverTypePostgres, "*", "name=${name}", &Page{OrderBy: order, Limit: 10, Cursor: cursor})
+	expected := "SELECT * FROM user WHERE (name=${name}) AND ((age < ${__k0}) OR (age = ${__k0} AND id > ${__k1})) ORDER BY age DESC,id LIMIT 10"
+	if err != nil || q != expected {
+		t.Errorf("unexpected query: %s %v", q, err)
+	}
+	if params[KeysetParamPrefix+"0"] != int64(12) || params[KeysetParamPrefix+"1"] != int64(1)<<60 {
+		t.Errorf("unexpected cursor params: %v", params)
+	}
+	if _, _, err = op.SqlSelectPage(DriverTypePostgres, "*", "1=1", &Page{OrderBy: order[:1], Cursor: cursor}); err != ErrBadCursor {
+		t.Errorf("expected ErrBadCursor, got %v", err)
+	}
+}
+
+func TestPageCursor(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	name := "x"
+	values := []interface{}{int8(-1), uint64(1) << 63, 1.5, &name, true, at, []byte("b"), sql.NullInt64{Int64: 7, Valid: true}}
+	columns := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	cursor, err := encodeCursor(columns, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeCursor(cursor, len(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{int64(-1), uint64(1) << 63, 1.5, "x", true, at, []byte("b"), int64(7)}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("unexpected cursor values: %#v", decoded)
+	}
+
+	for _, v := range []interface{}{nil, (*string)(nil), sql.NullTime{}, struct{}{}} {
+		if _, err = encodeCursor([]string{"a"}, []interface{}{v}); err == nil {
+			t.Errorf("`%#v` is encoded to a cursor", v)
+		}
+	}
+}
+
+type testEvent struct {
+	ID int64     `db:"id"`
+	At time.Time `db:"at"`
+}
+
+func (e *testEvent) TableName() string { return "event" }
+
+func (e *testEvent) TableColumns() []string { return nil }
+
+func TestOperator_SelectPage(t *testing.T) {
+	db := openTestSqlite(t)
+	useTestSqlite(t, db)
+	ctx := context.Background()
+	if _, err := db.Execute(ctx, "CREATE TABLE event (id INTEGER PRIMARY KEY, at DATETIME NOT NULL)", nil); err != nil {
+		t.Fatal(err)
+	}
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, hours := range []int{3, 1, 4, 1, 5} {
+		_, err := db.Execute(ctx, "INSERT INTO event (id, at) VALUES (${id}, ${at})", Params{"id": i + 1, "at": begin.Add(time.Duration(hours) * time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	op := NewOperator(&testEvent{})
+	page := &Page{OrderBy: []Order{{Column: "at", Desc: true}, {Column: "id"}}, Limit: 2}
+	var events []testEvent
+	for i := 0; ; i++ {
+		if i > 5 {
+			t.Fatalf("the cursor never ends, %d events", len(events))
+		}
+		cursor, err := op.SelectPage(ctx, "*", "1=1", nil, page, &events)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cursor) < 1 {
+			break
+		}
+		page.Cursor = cursor
+	}
+	var ids []int64
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	if !reflect.DeepEqual(ids, []int64{5, 3, 1, 2, 4}) {
+		t.Errorf("unexpected pages: %v", ids)
+	}
+}
@@ -1,8 +1,13 @@
 package sqlx
 
 import (
+	"errors"
 	"fmt"
+	"github.com/zzztttkkk/sqlx/reflectx"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type Params map[string]interface{}
@@ -15,10 +20,11 @@ func (p Params) Keys() []string {
 	if len(p) < 1 {
 		return nil
 	}
-	lst := make([]string, len(p))
+	lst := make([]string, 0, len(p))
 	for k := range p {
 		lst = append(lst, k)
 	}
+	sort.Strings(lst)
 	return lst
 }
 
@@ -27,10 +33,41 @@ var mapType = reflect.TypeOf(map[string]interface{}{})
 var paramSliceType = reflect.TypeOf(ParamSlice{})
 var sliceType = reflect.TypeOf([]interface{}{})
 
-func paramsToMap(params interface{}) (Params, error) {
+var ErrNilParams = errors.New("sqlx: nil params pointer")
+
+// indirectParams dereferences the pointers of params, the result is a struct or a map with string keys.
+func indirectParams(params interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, ErrNilParams
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return v, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return v, nil
+		}
+	}
+	return v, fmt.Errorf("sqlx: bad params value `%v`", params)
+}
+
+func paramsToMap(params interface{}) (Params, error) { return paramsToMapWith(params, nil) }
+
+// paramsToMapWith converts params to a map, struct fields are skipped if `skip` returns true.
+func paramsToMapWith(params interface{}, skip func(f *reflectx.FieldInfo, v reflect.Value) bool) (Params, error) {
 	if params == nil {
 		return nil, nil
 	}
+	switch v := params.(type) {
+	case *MergedParams:
+		return v.toMap()
+	case MergedParams:
+		return v.toMap()
+	}
 
 	t := reflect.TypeOf(params)
 	if t == paramsType {
@@ -40,14 +77,32 @@ func paramsToMap(params interface{}) (Params, error) {
 		return params.(map[string]interface{}), nil
 	}
 
-	if t.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("sqlx: bad params value `%v`", params)
+	pv, err := indirectParams(params)
+	if err != nil {
+		return nil, err
 	}
 
 	var m Params
-	var pv = reflect.ValueOf(params)
-	for n, f := range mapper.TypeMap(t).Names {
-		fv := pv.FieldByIndex(f.Index)
+	if pv.Kind() == reflect.Map {
+		if pv.Len() < 1 {
+			return nil, nil
+		}
+		m = make(Params, pv.Len())
+		iter := pv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m, nil
+	}
+
+	for n, f := range mapper.TypeMap(pv.Type()).Names {
+		fv, ok := fieldByIndex(pv, f.Index)
+		if !ok { // the field is under a nil pointer
+			continue
+		}
+		if skip != nil && skip(f, fv) {
+			continue
+		}
 		if m == nil {
 			m = make(Params)
 		}
@@ -56,55 +111,296 @@ func paramsToMap(params interface{}) (Params, error) {
 	return m, nil
 }
 
-func ParamsToArgs(params interface{}, keys []string) ([]interface{}, error) {
+// isPositional reports whether the key is a `${1}` like positional key.
+func isPositional(key string) bool {
+	if len(key) < 1 {
+		return false
+	}
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// sliceToArgs resolves keys against a slice. positional keys are 1-based indexes,
+// and each distinct named key takes the next index by the order of its first appearance.
+func sliceToArgs(lst []interface{}, keys []string) ([]interface{}, error) {
 	if len(keys) < 1 {
+		if len(lst) > 0 {
+			return nil, fmt.Errorf("sqlx: %d params given, but the query has no param", len(lst))
+		}
 		return nil, nil
 	}
 
-	t := reflect.TypeOf(params)
-	switch t {
-	case paramsType:
-		var args []interface{}
-		m := params.(Params)
-		for _, k := range keys {
-			v, ok := m[k]
+	used := make([]bool, len(lst))
+	named := map[string]int{}
+	next := 0
+	args := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		var idx int
+		if isPositional(k) {
+			idx, _ = strconv.Atoi(k)
+			idx--
+		} else {
+			i, ok := named[k]
 			if !ok {
-				return nil, fmt.Errorf("sqlx: missing key `%s`", k)
+				i = next
+				next++
+				named[k] = i
 			}
-			args = append(args, v)
+			idx = i
 		}
-		return args, nil
-	case mapType:
-		var args []interface{}
-		m := params.(map[string]interface{})
-		for _, k := range keys {
-			v, ok := m[k]
-			if !ok {
-				return nil, fmt.Errorf("sqlx: missing key `%s`", k)
+		if idx < 0 || idx >= len(lst) {
+			return nil, fmt.Errorf("sqlx: param `%s` out of range, %d params given", k, len(lst))
+		}
+		used[idx] = true
+		args = append(args, lst[idx])
+	}
+	for i, ok := range used {
+		if !ok {
+			return nil, fmt.Errorf("sqlx: param %d is not used by the query", i+1)
+		}
+	}
+	return args, nil
+}
+
+// NilPathAsNull makes a nil pointer along a dotted param path bind as NULL, instead of returning ErrNilPath.
+var NilPathAsNull = false
+
+var ErrNilPath = errors.New("sqlx: nil value in param path")
+
+var ErrMissingKey = errors.New("sqlx: missing key")
+
+func missingKey(key string) error { return fmt.Errorf("%w `%s`", ErrMissingKey, key) }
+
+// fieldByIndex is reflect.Value.FieldByIndex, but returns false instead of panic if it meets a nil pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return v, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// lookupPath resolves a dotted key, like `user.address.city`, through structs, maps, pointers and interfaces.
+// At each level the longest matched prefix wins, so a struct path of the mapper or a map key containing dots works too.
+func lookupPath(v reflect.Value, key string) (interface{}, error) {
+	segments := strings.Split(key, ".")
+	for len(segments) > 0 {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nilPath(key)
 			}
-			args = append(args, v)
+			v = v.Elem()
 		}
-		return args, nil
+
+		matched := false
+		switch v.Kind() {
+		case reflect.Struct:
+			smap := mapper.TypeMap(v.Type())
+			for n := len(segments); n > 0 && !matched; n-- {
+				fi := smap.GetByPath(strings.Join(segments[:n], "."))
+				if fi == nil {
+					continue
+				}
+				fv, ok := fieldByIndex(v, fi.Index)
+				if !ok {
+					return nilPath(key)
+				}
+				v = fv
+				segments = segments[n:]
+				matched = true
+			}
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				break
+			}
+			for n := len(segments); n > 0 && !matched; n-- {
+				mv := v.MapIndex(reflect.ValueOf(strings.Join(segments[:n], ".")).Convert(v.Type().Key()))
+				if !mv.IsValid() {
+					continue
+				}
+				v = mv
+				segments = segments[n:]
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, missingKey(key)
+		}
+	}
+	return v.Interface(), nil
+}
+
+func nilPath(key string) (interface{}, error) {
+	if NilPathAsNull {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w: `%s`", ErrNilPath, key)
+}
+
+func mapToArgs(m map[string]interface{}, keys []string) ([]interface{}, error) {
+	args := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		v, ok := m[k]
+		if !ok {
+			if !strings.Contains(k, ".") {
+				return nil, missingKey(k)
+			}
+			var err error
+			if v, err = lookupPath(reflect.ValueOf(m), k); err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, v)
+	}
+	return args, nil
+}
+
+func ParamsToArgs(params interface{}, keys []string) ([]interface{}, error) {
+	switch v := params.(type) {
+	case *MergedParams:
+		return v.args(keys)
+	case MergedParams:
+		return v.args(keys)
+	}
+
+	t := reflect.TypeOf(params)
+	switch t {
 	case sliceType:
-		return params.([]interface{}), nil
+		return sliceToArgs(params.([]interface{}), keys)
 	case paramSliceType:
-		return params.(ParamSlice), nil
+		return sliceToArgs(params.(ParamSlice), keys)
+	}
+
+	if len(keys) < 1 {
+		return nil, nil
+	}
+
+	switch t {
+	case paramsType:
+		return mapToArgs(params.(Params), keys)
+	case mapType:
+		return mapToArgs(params.(map[string]interface{}), keys)
 	}
 
-	if t.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("sqlx: bad params value `%v`", params)
+	pv, err := indirectParams(params)
+	if err != nil {
+		return nil, err
 	}
 
-	var args []interface{}
-	var m = mapper.TypeMap(t).Names
-	var pv = reflect.ValueOf(params)
+	args := make([]interface{}, 0, len(keys))
 	for _, k := range keys {
-		f, ok := m[k]
-		if !ok {
-			return nil, fmt.Errorf("sqlx: missing key `%s`", k)
+		v, err := lookupPath(pv, k)
+		if err != nil {
+			return nil, err
 		}
-		fv := pv.FieldByIndex(f.Index)
-		args = append(args, fv.Interface())
+		args = append(args, v)
 	}
 	return args, nil
 }
+
+type MergePrecedence int
+
+const (
+	FirstWins = MergePrecedence(iota)
+	LastWins
+)
+
+// MergedParams resolves keys against an ordered list of sources, each source is a struct, a map or Params.
+type MergedParams struct {
+	Sources    []interface{}
+	Precedence MergePrecedence
+	// Strict makes a key found in more than one source an error.
+	Strict bool
+}
+
+// Merge returns a MergedParams, the first source containing the key wins.
+func Merge(sources ...interface{}) *MergedParams { return &MergedParams{Sources: sources} }
+
+func (mp *MergedParams) ordered() []interface{} {
+	if mp.Precedence != LastWins {
+		return mp.Sources
+	}
+	lst := make([]interface{}, len(mp.Sources))
+	for i, src := range mp.Sources {
+		lst[len(lst)-1-i] = src
+	}
+	return lst
+}
+
+func (mp *MergedParams) args(keys []string) ([]interface{}, error) {
+	if len(keys) < 1 {
+		return nil, nil
+	}
+	sources := mp.ordered()
+	for _, src := range sources {
+		switch src.(type) {
+		case ParamSlice, []interface{}:
+			return nil, fmt.Errorf("sqlx: slice params can not be merged")
+		}
+	}
+
+	args := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		var arg interface{}
+		found := false
+		for _, src := range sources {
+			if src == nil {
+				continue
+			}
+			v, err := ParamsToArgs(src, []string{k})
+			if err != nil {
+				if errors.Is(err, ErrMissingKey) {
+					continue
+				}
+				return nil, err
+			}
+			if found {
+				return nil, fmt.Errorf("sqlx: ambiguous key `%s`", k)
+			}
+			arg = v[0]
+			found = true
+			if !mp.Strict {
+				break
+			}
+		}
+		if !found {
+			return nil, missingKey(k)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (mp *MergedParams) toMap() (Params, error) {
+	var m Params
+	for _, src := range mp.ordered() {
+		sm, err := paramsToMap(src)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range sm {
+			if _, ok := m[k]; ok {
+				if mp.Strict {
+					return nil, fmt.Errorf("sqlx: ambiguous key `%s`", k)
+				}
+				continue
+			}
+			if m == nil {
+				m = Params{}
+			}
+			m[k] = v
+		}
+	}
+	return m, nil
+}
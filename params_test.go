@@ -1,6 +1,7 @@
 package sqlx
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -24,3 +25,116 @@ func Test_paramsToMap(t *testing.T) {
 	fmt.Println(ParamsToArgs(map[string]interface{}{"X": 45}, []string{"X"}))
 	fmt.Println(ParamsToArgs(Params{"X": 45}, []string{"X"}))
 }
+
+func TestParamsToArgs_Path(t *testing.T) {
+	type Address struct {
+		City string `db:"city"`
+	}
+	type User struct {
+		Name    string                 `db:"name"`
+		Address *Address               `db:"address"`
+		Extra   map[string]interface{} `db:"extra"`
+	}
+	type Arg struct {
+		User *User `db:"user"`
+	}
+
+	arg := Arg{User: &User{Name: "ztk", Address: &Address{City: "a"}, Extra: map[string]interface{}{"tag": "b"}}}
+	keys := []string{"user.name", "user.address.city", "user.extra.tag"}
+	args, err := ParamsToArgs(arg, keys)
+	if err != nil || fmt.Sprint(args) != "[ztk a b]" {
+		t.Errorf("unexpected args: %v %v", args, err)
+	}
+	args, err = ParamsToArgs(Params{"u": arg.User, "m": map[string]interface{}{"x": Params{"y": 1}}}, []string{"u.address.city", "m.x.y"})
+	if err != nil || fmt.Sprint(args) != "[a 1]" {
+		t.Errorf("unexpected args: %v %v", args, err)
+	}
+	if _, err = ParamsToArgs(arg, []string{"user.address.town"}); err == nil {
+		t.Errorf("expected missing key error")
+	}
+
+	arg.User.Address = nil
+	if _, err = ParamsToArgs(arg, keys); !errors.Is(err, ErrNilPath) {
+		t.Errorf("expected ErrNilPath, got %v", err)
+	}
+	NilPathAsNull = true
+	defer func() { NilPathAsNull = false }()
+	args, err = ParamsToArgs(arg, keys)
+	if err != nil || args[1] != nil {
+		t.Errorf("unexpected args: %v %v", args, err)
+	}
+}
+
+func TestParamsToArgs_Indirect(t *testing.T) {
+	type Arg struct {
+		Name string `db:"name"`
+	}
+	type Name string
+
+	cases := []interface{}{
+		&Arg{Name: "ztk"},
+		map[string]string{"name": "ztk"},
+		map[Name]interface{}{"name": "ztk"},
+		&Params{"name": "ztk"},
+	}
+	for _, params := range cases {
+		args, err := ParamsToArgs(params, []string{"name"})
+		if err != nil || fmt.Sprint(args) != "[ztk]" {
+			t.Errorf("unexpected args of %T: %v %v", params, args, err)
+		}
+		m, err := paramsToMap(params)
+		if err != nil || fmt.Sprint(m["name"]) != "ztk" {
+			t.Errorf("unexpected map of %T: %v %v", params, m, err)
+		}
+	}
+
+	var nilArg *Arg
+	if _, err := ParamsToArgs(nilArg, []string{"name"}); !errors.Is(err, ErrNilParams) {
+		t.Errorf("expected ErrNilParams, got %v", err)
+	}
+	if _, err := paramsToMap(nilArg); !errors.Is(err, ErrNilParams) {
+		t.Errorf("expected ErrNilParams, got %v", err)
+	}
+	if _, err := ParamsToArgs(map[int]interface{}{1: 1}, []string{"1"}); err == nil {
+		t.Errorf("expected bad params error")
+	}
+}
+
+func TestMergedParams(t *testing.T) {
+	type Req struct {
+		UID  int64  `db:"uid"`
+		Name string `db:"name"`
+	}
+	req := &Req{UID: 1, Name: "a"}
+	keys := []string{"uid", "name", "now"}
+
+	args, err := ParamsToArgs(Merge(req, Params{"name": "b", "now": 3}), keys)
+	if err != nil || fmt.Sprint(args) != "[1 a 3]" {
+		t.Errorf("unexpected args: %v %v", args, err)
+	}
+	args, err = ParamsToArgs(&MergedParams{Sources: []interface{}{req, Params{"name": "b", "now": 3}}, Precedence: LastWins}, keys)
+	if err != nil || fmt.Sprint(args) != "[1 b 3]" {
+		t.Errorf("unexpected args: %v %v", args, err)
+	}
+	_, err = ParamsToArgs(&MergedParams{Sources: []interface{}{req, Params{"name": "b", "now": 3}}, Strict: true}, keys)
+	if err == nil {
+		t.Errorf("expected ambiguous key error")
+	}
+	if _, err = ParamsToArgs(Merge(req), keys); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("expected ErrMissingKey, got %v", err)
+	}
+
+	m, err := paramsToMap(Merge(Params{"name": "b"}, req))
+	if err != nil || len(m) != 2 || m["name"] != "b" {
+		t.Errorf("unexpected map: %v %v", m, err)
+	}
+}
+
+func TestParams_Keys(t *testing.T) {
+	if keys := (Params{"b": 1, "a": 2, "c": 3}).Keys(); len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if keys := (Params{}).Keys(); keys != nil {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
@@ -0,0 +1,151 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+var ErrNoPrimaryKey = errors.New("sqlx: model has no primary key")
+
+const pkParam = "__pk"
+
+// PrimaryKey returns the column tagged `pk`, or `id` if there is no such tag.
+func (op *Operator) PrimaryKey() string { return op.pk }
+
+func (op *Operator) isIntPK() bool {
+	f := op.smap.Names[op.pk]
+	if f == nil {
+		return false
+	}
+	switch f.Field.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// sqlSelectByPK renders `SELECT keys FROM table WHERE pk=${__pk}`, or `pk IN (${__pk})` if many.
+func (op *Operator) sqlSelectByPK(keys []string, many bool) string {
+	var buf strings.Builder
+	buf.WriteString("SELECT ")
+	buf.WriteString(strings.Join(keys, ","))
+	buf.WriteString(" FROM ")
+	buf.WriteString(op.model.TableName())
+	buf.WriteString(" WHERE ")
+	buf.WriteString(op.pk)
+	if many {
+		buf.WriteString(" IN (${" + pkParam + "})")
+	} else {
+		buf.WriteString("=${" + pkParam + "}")
+	}
+	return buf.String()
+}
+
+// scanReturning runs the query and scans the returned rows, the count of them is returned.
+func (op *Operator) scanReturning(ctx context.Context, exe Executor, query string, params interface{}, returning *Returning) (int64, error) {
+	if returning.Rows != nil {
+		sliceV := reflect.ValueOf(returning.Rows).Elem()
+		l := sliceV.Len()
+		if err := exe.Select(ctx, query, params, returning.Rows); err != nil {
+			return 0, err
+		}
+		return int64(sliceV.Len() - l), nil
+	}
+
+	rows, err := exe.Rows(ctx, query, params)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	if err = rows.Scan(returning.Dists); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// inTx runs fn in the executor if it is a Tx, otherwise in a new Tx of the DB.
+func inTx(ctx context.Context, exe Executor, fn func(ctx context.Context, exe Executor) error) error {
+	db, ok := exe.(*DB)
+	if !ok {
+		return fn(ctx, exe)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(WithTx(ctx, tx), tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertEmulated inserts a row, then selects the returning keys by LastInsertId in the same executor.
+func (op *Operator) insertEmulated(ctx context.Context, exe Executor, keys []string, pm Params, returning *Returning) error {
+	if len(op.pk) < 1 {
+		return ErrNoPrimaryKey
+	}
+	r, err := exe.Execute(ctx, op.SqlInsert(keys, nil), pm)
+	if err != nil {
+		return err
+	}
+	pk, ok := pm[op.pk]
+	if !ok {
+		if pk, err = r.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	_, err = op.scanReturning(ctx, exe, op.sqlSelectByPK(returning.Keys, false), Params{pkParam: pk}, returning)
+	return err
+}
+
+// updateEmulated locks the primary keys of matched rows, updates them, then selects the returning keys, in a transaction.
+func (op *Operator) updateEmulated(
+	ctx context.Context, exe Executor,
+	condition string, columns []string, params interface{},
+	returning *Returning,
+) (int64, error) {
+	if len(op.pk) < 1 {
+		return 0, ErrNoPrimaryKey
+	}
+	if op.isVersioned(columns) {
+		condition = op.versionCondition(condition)
+	}
+	var n int64
+	err := inTx(ctx, exe, func(ctx context.Context, exe Executor) error {
+		var buf strings.Builder
+		buf.WriteString("SELECT ")
+		buf.WriteString(op.pk)
+		buf.WriteString(" FROM ")
+		buf.WriteString(op.model.TableName())
+		buf.WriteString(" WHERE ")
+		buf.WriteString(condition)
+		if exe.DriverType() == DriverTypeMysql {
+			buf.WriteString(" FOR UPDATE")
+		}
+		var pks []interface{}
+		if err := exe.Select(ctx, buf.String(), params, &pks); err != nil {
+			return err
+		}
+		if len(pks) < 1 {
+			return nil
+		}
+
+		pkParams := Params{pkParam: pks}
+		q := op.SqlUpdate(op.pk+" IN (${"+pkParam+"})", columns, nil)
+		if _, err := exe.Execute(ctx, q, Merge(pkParams, params)); err != nil {
+			return err
+		}
+		var err error
+		n, err = op.scanReturning(ctx, exe, op.sqlSelectByPK(returning.Keys, true), pkParams, returning)
+		return err
+	})
+	return n, err
+}
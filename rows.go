@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/zzztttkkk/sqlx/reflectx"
 	"reflect"
+	"time"
 )
 
 type Rows struct {
@@ -38,10 +39,21 @@ func (rows *Rows) Scan(dist interface{}) error {
 	if t.Kind() == reflect.Map && isMapType(t) {
 		return rows.scanMap(&v)
 	}
-	return ErrUnexpectedDistType
+	if _, ok := dist.(sql.Scanner); ok || t == timeType {
+		return rows.Rows.Scan(dist)
+	}
+	if t.Kind() == reflect.Struct {
+		return rows.scanStruct(&v)
+	}
+	if t.Kind() == reflect.Map || t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		return ErrUnexpectedDistType
+	}
+	// scalar of a single column
+	return rows.Rows.Scan(dist)
 }
 
 var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+var timeType = reflect.TypeOf(time.Time{})
 
 func (rows *Rows) scanMap(v *reflect.Value) error {
 	var m map[string]interface{}
@@ -106,9 +118,10 @@ func (rows *Rows) selectToValueSlice(sliceV reflect.Value, et reflect.Type) (*re
 		doAppend := true
 		var eleV reflect.Value
 		var elePtr interface{}
-		if l < sliceV.Cap() {
+		if l <= sliceV.Cap() && sliceV.CanSet() {
 			doAppend = false
 			sliceV.SetLen(l)
+			eleV = sliceV.Index(l - 1)
 			elePtr = eleV.Addr().Interface()
 		} else {
 			elePtrV := reflect.New(et)
@@ -0,0 +1,72 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testRow struct {
+	K string `db:"k"`
+	V int64  `db:"v"`
+}
+
+func TestRows_Scan(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	for _, k := range []string{"a", "b", "c"} {
+		insertKV(t, db, k)
+	}
+
+	var row testRow
+	if err := db.Get(ctx, "SELECT k, v FROM kv WHERE k='b'", nil, &row); err != nil || row.K != "b" || row.V != 1 {
+		t.Errorf("unexpected struct: %v %v", row, err)
+	}
+	var n int64
+	if err := db.Get(ctx, "SELECT COUNT(*) FROM kv", nil, &n); err != nil || n != 3 {
+		t.Errorf("unexpected scalar: %d %v", n, err)
+	}
+	var k sql.NullString
+	if err := db.Get(ctx, "SELECT k FROM kv WHERE k='c'", nil, &k); err != nil || k.String != "c" {
+		t.Errorf("unexpected scanner: %v %v", k, err)
+	}
+	if _, err := db.Execute(ctx, "CREATE TABLE ev (at DATETIME)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Execute(ctx, "INSERT INTO ev (at) VALUES (${t})", Params{"t": time.Unix(100, 0).UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	var tm time.Time
+	if err := db.Get(ctx, "SELECT at FROM ev", nil, &tm); err != nil || tm.Unix() != 100 {
+		t.Errorf("unexpected time: %v %v", tm, err)
+	}
+	var lst []int64
+	if err := db.Get(ctx, "SELECT v FROM kv", nil, &lst); !errors.Is(err, ErrUnexpectedDistType) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRows_SelectToValueSlice(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	for _, k := range []string{"a", "b", "c"} {
+		insertKV(t, db, k)
+	}
+
+	// the spare capacity is reused, and each row is scanned into its own element
+	lst := make([]testRow, 1, 8)
+	lst[0] = testRow{K: "x"}
+	if err := db.Select(ctx, "SELECT k, v FROM kv ORDER BY k", nil, &lst); err != nil {
+		t.Fatal(err)
+	}
+	if len(lst) != 4 || lst[0].K != "x" || lst[1].K != "a" || lst[2].K != "b" || lst[3].K != "c" {
+		t.Errorf("unexpected rows: %v", lst)
+	}
+
+	var keys []string
+	if err := db.Select(ctx, "SELECT k FROM kv ORDER BY k", nil, &keys); err != nil || len(keys) != 3 || keys[2] != "c" {
+		t.Errorf("unexpected rows: %v %v", keys, err)
+	}
+}
@@ -0,0 +1,333 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var nullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+	reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(uint8(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullTime{}):    timeType,
+}
+
+// columnType maps a field type to the column type of the driver.
+// `keyed` is true if the column is a part of a key, mysql can not index TEXT columns without a length.
+func columnType(d DriverType, t reflect.Type, size int, keyed bool) (string, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if nt, ok := nullTypes[t]; ok {
+		t = nt
+	}
+	if t == timeType {
+		if d == DriverTypePostgres {
+			return "TIMESTAMP WITH TIME ZONE", nil
+		}
+		return "DATETIME", nil
+	}
+
+	mysql := d == DriverTypeMysql
+	postgres := d == DriverTypePostgres
+	switch t.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return intColumnType(d, t.Kind()), nil
+	case reflect.Float32:
+		if mysql {
+			return "FLOAT", nil
+		}
+		return "REAL", nil
+	case reflect.Float64:
+		switch {
+		case mysql:
+			return "DOUBLE", nil
+		case postgres:
+			return "DOUBLE PRECISION", nil
+		}
+		return "REAL", nil
+	case reflect.String:
+		if size > 0 {
+			return "VARCHAR(" + strconv.Itoa(size) + ")", nil
+		}
+		if mysql && keyed {
+			return "VARCHAR(255)", nil
+		}
+		return "TEXT", nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if postgres {
+				return "BYTEA", nil
+			}
+			return "BLOB", nil
+		}
+	}
+	return "", fmt.Errorf("sqlx: can not map type `%s` to a column type", t)
+}
+
+func intColumnType(d DriverType, k reflect.Kind) string {
+	switch d {
+	case DriverTypeSqlite3:
+		return "INTEGER"
+	case DriverTypeMysql:
+		var v string
+		switch k {
+		case reflect.Int8, reflect.Uint8:
+			v = "TINYINT"
+		case reflect.Int16, reflect.Uint16:
+			v = "SMALLINT"
+		case reflect.Int32, reflect.Uint32:
+			v = "INT"
+		default:
+			v = "BIGINT"
+		}
+		switch k {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v += " UNSIGNED"
+		}
+		return v
+	default: // postgres has no unsigned integers, so they take the next wider type
+		switch k {
+		case reflect.Int8, reflect.Int16, reflect.Uint8:
+			return "SMALLINT"
+		case reflect.Int32, reflect.Uint16:
+			return "INTEGER"
+		case reflect.Uint, reflect.Uint64:
+			return "NUMERIC(20)"
+		default:
+			return "BIGINT"
+		}
+	}
+}
+
+// Index is a separated index of the table, built from the `index` and `unique=name` tag options.
+type Index struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// Indexes returns the indexes of the model. `index` indexes a column, and the columns sharing
+// a name, like `index=idx_a_b` or `unique=uk_a_b`, make a composite index.
+func (op *Operator) Indexes() []Index {
+	var lst []Index
+	named := map[string]int{}
+	add := func(name string, unique bool, column string) {
+		if ind, ok := named[name]; ok {
+			lst[ind].Columns = append(lst[ind].Columns, column)
+			return
+		}
+		named[name] = len(lst)
+		lst = append(lst, Index{Name: name, Unique: unique, Columns: []string{column}})
+	}
+
+	table := TableNamePrefix + op.model.TableName()
+	for _, n := range orderedNames(op.smap) {
+		opts := op.smap.Names[n].Options
+		if name, ok := opts["index"]; ok {
+			if len(name) < 1 {
+				name = "idx_" + table + "_" + n
+			}
+			add(name, false, n)
+		}
+		if name := opts["unique"]; len(name) > 0 {
+			add(name, true, n)
+		}
+	}
+	return lst
+}
+
+// primaryKeys returns the columns tagged `pk`, or the primary key of the operator.
+func (op *Operator) primaryKeys() []string {
+	var lst []string
+	for _, n := range orderedNames(op.smap) {
+		if _, ok := op.smap.Names[n].Options["pk"]; ok {
+			lst = append(lst, n)
+		}
+	}
+	if len(lst) < 1 && len(op.pk) > 0 {
+		lst = append(lst, op.pk)
+	}
+	return lst
+}
+
+// fieldColumnType returns the column type of the field, by the field type and the `size` tag option.
+func (op *Operator) fieldColumnType(d DriverType, name string) (string, error) {
+	f := op.smap.Names[name]
+	opts := f.Options
+	_, pk := opts["pk"]
+	_, index := opts["index"]
+	_, unique := opts["unique"]
+	size := 0
+	if v, ok := opts["size"]; ok {
+		var err error
+		if size, err = strconv.Atoi(v); err != nil {
+			return "", fmt.Errorf("sqlx: bad size `%s` of column `%s`", v, name)
+		}
+	}
+	typ, err := columnType(d, f.Field.Type, size, pk || unique || index || op.pk == name)
+	if err != nil {
+		return "", fmt.Errorf("%w, column `%s`", err, name)
+	}
+	return typ, nil
+}
+
+// columnDefinition renders the DDL of one column from the field type and the tag options
+// `pk`, `autoincrement`, `notnull`, `unique`, `size=50` and `default=...`.
+func (op *Operator) columnDefinition(d DriverType, name string, inlinePK bool) (string, error) {
+	opts := op.smap.Names[name].Options
+	_, autoincrement := opts["autoincrement"]
+	_, notnull := opts["notnull"]
+	unique, isUnique := opts["unique"]
+
+	typ, err := op.fieldColumnType(d, name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	buf.WriteString(name)
+	buf.WriteByte(' ')
+	switch {
+	case autoincrement && d == DriverTypePostgres:
+		if typ == "BIGINT" {
+			buf.WriteString("BIGSERIAL")
+		} else {
+			buf.WriteString("SERIAL")
+		}
+	case autoincrement && d == DriverTypeSqlite3:
+		if !inlinePK {
+			return "", fmt.Errorf("sqlx: sqlite3 autoincrement column `%s` must be the only primary key", name)
+		}
+		buf.WriteString("INTEGER PRIMARY KEY AUTOINCREMENT")
+		return buf.String(), nil
+	default:
+		buf.WriteString(typ)
+	}
+	if notnull {
+		buf.WriteString(" NOT NULL")
+	}
+	if autoincrement && d == DriverTypeMysql {
+		buf.WriteString(" AUTO_INCREMENT")
+	}
+	if inlinePK {
+		buf.WriteString(" PRIMARY KEY")
+	}
+	if isUnique && len(unique) < 1 {
+		buf.WriteString(" UNIQUE")
+	}
+	if v := opts["default"]; len(v) > 0 {
+		buf.WriteString(" DEFAULT ")
+		buf.WriteString(v)
+	}
+	return buf.String(), nil
+}
+
+// TableDefinitions returns the column and constraint definitions of the table.
+// A non-empty Model.TableColumns() overrides the ones derived from the struct tags.
+func (op *Operator) TableDefinitions(d DriverType) ([]string, error) {
+	if lst := op.model.TableColumns(); len(lst) > 0 {
+		return lst, nil
+	}
+
+	pks := op.primaryKeys()
+	var lst []string
+	for _, n := range orderedNames(op.smap) {
+		def, err := op.columnDefinition(d, n, len(pks) == 1 && pks[0] == n)
+		if err != nil {
+			return nil, err
+		}
+		lst = append(lst, def)
+	}
+	if len(pks) > 1 {
+		lst = append(lst, "PRIMARY KEY ("+strings.Join(pks, ", ")+")")
+	}
+	return lst, nil
+}
+
+func (op *Operator) SqlCreateIndex(d DriverType, index Index) string {
+	var buf strings.Builder
+	buf.WriteString("CREATE ")
+	if index.Unique {
+		buf.WriteString("UNIQUE ")
+	}
+	buf.WriteString("INDEX ")
+	if d != DriverTypeMysql { // mysql does not support `IF NOT EXISTS` of indexes
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	buf.WriteString(index.Name)
+	buf.WriteString(" ON ")
+	buf.WriteString(TableNamePrefix)
+	buf.WriteString(op.model.TableName())
+	buf.WriteString(" (")
+	buf.WriteString(strings.Join(index.Columns, ", "))
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// SqlCreateTable renders the CREATE TABLE statement, followed by the CREATE INDEX statements.
+func (op *Operator) SqlCreateTable(d DriverType) ([]string, error) {
+	defs, err := op.TableDefinitions(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf strings.Builder
+	buf.WriteString("CREATE TABLE IF NOT EXISTS ")
+	buf.WriteString(TableNamePrefix)
+	buf.WriteString(op.model.TableName())
+	buf.WriteString(" (")
+	buf.WriteString(strings.Join(defs, ", "))
+	buf.WriteString(")")
+
+	lst := []string{buf.String()}
+	for _, index := range op.Indexes() {
+		lst = append(lst, op.SqlCreateIndex(d, index))
+	}
+	return lst, nil
+}
+
+// CreateTable creates the table and its indexes in the writeable DB.
+func (op *Operator) CreateTable(ctx context.Context) error {
+	d := wDB.DriverType()
+	lst, err := op.SqlCreateTable(d)
+	if err != nil {
+		return err
+	}
+	if _, err = wDB.Execute(ctx, lst[0], nil); err != nil {
+		return err
+	}
+
+	for i, index := range op.Indexes() {
+		if d == DriverTypeMysql {
+			var count int64
+			err = wDB.Get(
+				ctx,
+				"SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema=DATABASE() AND table_name=${table} AND index_name=${index}",
+				Params{"table": TableNamePrefix + op.model.TableName(), "index": index.Name},
+				&count,
+			)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+		}
+		if _, err = wDB.Execute(ctx, lst[i+1], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testAccount struct {
+	ID        int64     `db:"id,pk,autoincrement"`
+	Email     string    `db:"email,notnull,unique"`
+	Name      string    `db:"name,size=50,notnull,index"`
+	Org       int32     `db:"org,unique=uk_account_org_code"`
+	Code      string    `db:"code,unique=uk_account_org_code"`
+	Score     float64   `db:"score,default=0"`
+	Avatar    []byte    `db:"avatar"`
+	Admin     *bool     `db:"admin"`
+	CreatedAt time.Time `db:"created_at,notnull,default=CURRENT_TIMESTAMP"`
+}
+
+func (a *testAccount) TableName() string { return "account" }
+
+func (a *testAccount) TableColumns() []string { return nil }
+
+func TestOperator_SqlCreateTable(t *testing.T) {
+	op := NewOperator(&testAccount{})
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_account_name ON account (name)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS uk_account_org_code ON account (org, code)",
+	}
+	cases := map[DriverType][]string{
+		DriverTypeSqlite3: append([]string{
+			"CREATE TABLE IF NOT EXISTS account (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE, " +
+				"name VARCHAR(50) NOT NULL, org INTEGER, code TEXT, score REAL DEFAULT 0, avatar BLOB, admin BOOLEAN, " +
+				"created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+		}, indexes...),
+		DriverTypePostgres: append([]string{
+			"CREATE TABLE IF NOT EXISTS account (id BIGSERIAL PRIMARY KEY, email TEXT NOT NULL UNIQUE, " +
+				"name VARCHAR(50) NOT NULL, org INTEGER, code TEXT, score DOUBLE PRECISION DEFAULT 0, avatar BYTEA, admin BOOLEAN, " +
+				"created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+		}, indexes...),
+		DriverTypeMysql: {
+			"CREATE TABLE IF NOT EXISTS account (id BIGINT AUTO_INCREMENT PRIMARY KEY, email VARCHAR(255) NOT NULL UNIQUE, " +
+				"name VARCHAR(50) NOT NULL, org INT, code VARCHAR(255), score DOUBLE DEFAULT 0, avatar BLOB, admin BOOLEAN, " +
+				"created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE INDEX idx_account_name ON account (name)",
+			"CREATE UNIQUE INDEX uk_account_org_code ON account (org, code)",
+		},
+	}
+	for d, expected := range cases {
+		lst, err := op.SqlCreateTable(d)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(lst, expected) {
+			t.Errorf("unexpected statements of %d:\n%v\n%v", d, lst, expected)
+		}
+	}
+}
+
+type testComposite struct {
+	UserID int64  `db:"user_id,pk"`
+	RoleID int64  `db:"role_id,pk"`
+	Note   string `db:"note"`
+}
+
+func (c *testComposite) TableName() string { return "user_role" }
+
+func (c *testComposite) TableColumns() []string { return []string{"user_id BIGINT", "role_id BIGINT"} }
+
+func TestOperator_TableDefinitions(t *testing.T) {
+	op := NewOperator(&testComposite{})
+	lst, err := op.TableDefinitions(DriverTypePostgres)
+	if err != nil || !reflect.DeepEqual(lst, []string{"user_id BIGINT", "role_id BIGINT"}) {
+		t.Errorf("unexpected definitions: %v %v", lst, err)
+	}
+
+	lst, err = NewOperator(&testCompositeTags{}).TableDefinitions(DriverTypePostgres)
+	expected := []string{"user_id BIGINT", "role_id BIGINT", "note TEXT", "PRIMARY KEY (user_id, role_id)"}
+	if err != nil || !reflect.DeepEqual(lst, expected) {
+		t.Errorf("unexpected definitions: %v %v", lst, err)
+	}
+}
+
+type testCompositeTags struct{ testComposite }
+
+func (c *testCompositeTags) TableColumns() []string { return nil }
+
+type testProfileMeta struct {
+	Theme string `db:"theme"`
+}
+
+type testProfile struct {
+	testBase
+	Nick  sql.NullString  `db:"nick"`
+	Seen  sql.NullTime    `db:"seen"`
+	Score sql.NullFloat64 `db:"score"`
+	Meta  testProfileMeta `db:"meta"`
+}
+
+func (p *testProfile) TableName() string { return "profile" }
+
+func (p *testProfile) TableColumns() []string { return nil }
+
+func TestOperator_NestedFields(t *testing.T) {
+	op := NewOperator(&testProfile{})
+	if lst := op.Group("*", true); !reflect.DeepEqual(lst, []string{"id", "created_at", "nick", "seen", "score", "meta"}) {
+		t.Errorf("unexpected columns: %v", lst)
+	}
+	if _, err := op.TableDefinitions(DriverTypeSqlite3); err == nil || !strings.Contains(err.Error(), "column `meta`") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	dm, err := writeData(&testProfile{Nick: sql.NullString{String: "x", Valid: true}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := dm.Keys(); !reflect.DeepEqual(keys, []string{"created_at", "meta", "nick", "score", "seen"}) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if dm["nick"] != (sql.NullString{String: "x", Valid: true}) {
+		t.Errorf("unexpected value: %v", dm["nick"])
+	}
+}
+
+type testNullable struct {
+	ID   int64          `db:"id,pk,autoincrement"`
+	Nick sql.NullString `db:"nick,size=20"`
+	Age  sql.NullInt32  `db:"age,index"`
+	Seen sql.NullTime   `db:"seen"`
+}
+
+func (n *testNullable) TableName() string { return "nullable" }
+
+func (n *testNullable) TableColumns() []string { return nil }
+
+func TestOperator_SqlCreateTableNullTypes(t *testing.T) {
+	lst, err := NewOperator(&testNullable{}).SqlCreateTable(DriverTypePostgres)
+	expected := []string{
+		"CREATE TABLE IF NOT EXISTS nullable (id BIGSERIAL PRIMARY KEY, nick VARCHAR(20), age INTEGER, seen TIMESTAMP WITH TIME ZONE)",
+		"CREATE INDEX IF NOT EXISTS idx_nullable_age ON nullable (age)",
+	}
+	if err != nil || !reflect.DeepEqual(lst, expected) {
+		t.Errorf("unexpected statements: %v %v", lst, err)
+	}
+}
+
+type testAutoIncrement struct {
+	ID    int64  `db:"id,pk,autoincrement"`
+	Title string `db:"title,notnull"`
+}
+
+func (a *testAutoIncrement) TableName() string { return "auto_increment" }
+
+func (a *testAutoIncrement) TableColumns() []string { return nil }
+
+func TestOperator_InsertAutoIncrement(t *testing.T) {
+	useTestSqlite(t, openTestSqlite(t))
+	ctx := context.Background()
+	op := NewOperator(&testAutoIncrement{})
+	if err := op.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []int64{1, 2} {
+		id, err := op.Insert(ctx, &testAutoIncrement{Title: "a"}, nil)
+		if err != nil || id != expected {
+			t.Errorf("unexpected insert %d: %d %v", i, id, err)
+		}
+	}
+	n, err := op.InsertMany(ctx, []testAutoIncrement{{Title: "b"}, {Title: "c"}}, nil)
+	if err != nil || n != 2 {
+		t.Errorf("unexpected insert: %d %v", n, err)
+	}
+	id, err := op.Insert(ctx, &testAutoIncrement{ID: 10, Title: "d"}, nil)
+	if err != nil || id != 10 {
+		t.Errorf("unexpected insert: %d %v", id, err)
+	}
+	if count, _ := op.Count(ctx, "1=1", nil); count != 5 {
+		t.Errorf("unexpected count: %d", count)
+	}
+}
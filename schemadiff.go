@@ -0,0 +1,249 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ColumnTypeDiff struct {
+	Column   string
+	Expected string
+	Actual   string
+}
+
+// TableDiff is the drift between a model and its table in the live database.
+type TableDiff struct {
+	Table          string
+	MissingTable   bool
+	MissingColumns []string
+	ExtraColumns   []string
+	// TypeMismatches is only checked for the models without Model.TableColumns().
+	TypeMismatches []ColumnTypeDiff
+
+	op         *Operator
+	driverType DriverType
+}
+
+func (td *TableDiff) IsEmpty() bool {
+	return !td.MissingTable && len(td.MissingColumns) < 1 && len(td.ExtraColumns) < 1 && len(td.TypeMismatches) < 1
+}
+
+func (td *TableDiff) String() string {
+	if td.MissingTable {
+		return fmt.Sprintf("table `%s` is missing", td.Table)
+	}
+	var lst []string
+	if len(td.MissingColumns) > 0 {
+		lst = append(lst, fmt.Sprintf("missing columns `%s`", strings.Join(td.MissingColumns, ",")))
+	}
+	if len(td.ExtraColumns) > 0 {
+		lst = append(lst, fmt.Sprintf("extra columns `%s`", strings.Join(td.ExtraColumns, ",")))
+	}
+	for _, v := range td.TypeMismatches {
+		lst = append(lst, fmt.Sprintf("column `%s` is `%s`, expected `%s`", v.Column, v.Actual, v.Expected))
+	}
+	return fmt.Sprintf("table `%s`: %s", td.Table, strings.Join(lst, "; "))
+}
+
+// AlterStatements renders the statements fixing the drift. Extra columns are dropped only if dropExtra is true,
+// and type mismatches are skipped on sqlite, which can not alter the type of a column.
+func (td *TableDiff) AlterStatements(dropExtra bool) ([]string, error) {
+	if td.MissingTable {
+		return td.op.SqlCreateTable(td.driverType)
+	}
+
+	var lst []string
+	for _, c := range td.MissingColumns {
+		def, err := td.op.columnDefinition(td.driverType, c, false)
+		if err != nil {
+			return nil, err
+		}
+		lst = append(lst, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", td.Table, def))
+	}
+	for _, v := range td.TypeMismatches {
+		switch td.driverType {
+		case DriverTypePostgres:
+			lst = append(lst, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", td.Table, v.Column, v.Expected))
+		case DriverTypeMysql:
+			def, err := td.op.mysqlModifyDefinition(v.Column)
+			if err != nil {
+				return nil, err
+			}
+			lst = append(lst, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", td.Table, def))
+		}
+	}
+	if dropExtra {
+		for _, c := range td.ExtraColumns {
+			lst = append(lst, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", td.Table, c))
+		}
+	}
+	return lst, nil
+}
+
+// mysqlModifyDefinition renders the column of MODIFY COLUMN. The keys of the column already exist,
+// and each UNIQUE of MODIFY adds one more index, so only the column attributes are rendered.
+// AUTO_INCREMENT is kept, MODIFY drops the attributes it does not repeat.
+func (op *Operator) mysqlModifyDefinition(name string) (string, error) {
+	opts := op.smap.Names[name].Options
+	typ, err := op.fieldColumnType(DriverTypeMysql, name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	buf.WriteString(name)
+	buf.WriteByte(' ')
+	buf.WriteString(typ)
+	if _, ok := opts["notnull"]; ok {
+		buf.WriteString(" NOT NULL")
+	}
+	if _, ok := opts["autoincrement"]; ok {
+		buf.WriteString(" AUTO_INCREMENT")
+	}
+	if v := opts["default"]; len(v) > 0 {
+		buf.WriteString(" DEFAULT ")
+		buf.WriteString(v)
+	}
+	return buf.String(), nil
+}
+
+type _LiveColumn struct {
+	name string
+	typ  string
+}
+
+// liveColumns reflects the columns of the table, an empty result means the table does not exist.
+func liveColumns(ctx context.Context, db *DB, table string) ([]_LiveColumn, error) {
+	d := db.DriverType()
+	var q string
+	switch d {
+	case DriverTypePostgres:
+		q = "SELECT column_name, data_type, character_maximum_length FROM information_schema.columns " +
+			"WHERE table_schema=current_schema() AND table_name=${table} ORDER BY ordinal_position"
+	case DriverTypeMysql:
+		q = "SELECT column_name, column_type, NULL FROM information_schema.columns " +
+			"WHERE table_schema=DATABASE() AND table_name=${table} ORDER BY ordinal_position"
+	default:
+		q = "SELECT name, type, NULL FROM pragma_table_info(${table}) ORDER BY cid"
+	}
+
+	rows, err := db.Rows(ctx, q, Params{"table": table})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lst []_LiveColumn
+	for rows.Next() {
+		var c _LiveColumn
+		var size sql.NullInt64
+		if err = rows.Rows.Scan(&c.name, &c.typ, &size); err != nil {
+			return nil, err
+		}
+		if d == DriverTypePostgres && c.typ == "character varying" && size.Valid {
+			c.typ = "varchar(" + strconv.FormatInt(size.Int64, 10) + ")"
+		}
+		lst = append(lst, c)
+	}
+	return lst, rows.Err()
+}
+
+var columnTypeAliases = map[string]string{
+	"int2":              "smallint",
+	"int4":              "integer",
+	"int8":              "bigint",
+	"serial":            "integer",
+	"bigserial":         "bigint",
+	"bool":              "boolean",
+	"float4":            "real",
+	"float8":            "double precision",
+	"character varying": "varchar",
+	"timestamptz":       "timestamp with time zone",
+}
+
+var mysqlIntWidthRegexp = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+
+// normalizeColumnType makes the spellings of the same type equal, like `int8` and `BIGINT` on postgres.
+func normalizeColumnType(d DriverType, t string) string {
+	t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+	if v, ok := columnTypeAliases[t]; ok {
+		t = v
+	}
+	switch d {
+	case DriverTypeMysql:
+		if t == "boolean" || t == "bool" {
+			return "tinyint(1)"
+		}
+		if t != "tinyint(1)" { // the display width is meaningless, except that `tinyint(1)` is a boolean
+			t = mysqlIntWidthRegexp.ReplaceAllString(t, "$1")
+		}
+	case DriverTypePostgres:
+		if strings.HasPrefix(t, "numeric(") {
+			t = "numeric"
+		}
+	}
+	return t
+}
+
+// DiffTable compares the model of the operator with its table in the live database.
+func (op *Operator) DiffTable(ctx context.Context, db *DB) (*TableDiff, error) {
+	d := db.DriverType()
+	table := TableNamePrefix + op.model.TableName()
+	td := &TableDiff{Table: table, op: op, driverType: d}
+
+	live, err := liveColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(live) < 1 {
+		td.MissingTable = true
+		return td, nil
+	}
+
+	liveTypes := make(map[string]string, len(live))
+	for _, c := range live {
+		liveTypes[c.name] = c.typ
+	}
+	checkType := len(op.model.TableColumns()) < 1
+	for _, n := range orderedNames(op.smap) {
+		actual, ok := liveTypes[n]
+		if !ok {
+			td.MissingColumns = append(td.MissingColumns, n)
+			continue
+		}
+		if !checkType {
+			continue
+		}
+		expected, err := op.fieldColumnType(d, n)
+		if err != nil {
+			return nil, err
+		}
+		if normalizeColumnType(d, expected) != normalizeColumnType(d, actual) {
+			td.TypeMismatches = append(td.TypeMismatches, ColumnTypeDiff{Column: n, Expected: expected, Actual: actual})
+		}
+	}
+	for _, c := range live {
+		if op.smap.Names[c.name] == nil {
+			td.ExtraColumns = append(td.ExtraColumns, c.name)
+		}
+	}
+	return td, nil
+}
+
+// DiffSchema compares the models with the live database, only the drifted tables are returned.
+func DiffSchema(ctx context.Context, db *DB, ops ...*Operator) ([]*TableDiff, error) {
+	var lst []*TableDiff
+	for _, op := range ops {
+		td, err := op.DiffTable(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if !td.IsEmpty() {
+			lst = append(lst, td)
+		}
+	}
+	return lst, nil
+}
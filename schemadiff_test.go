@@ -0,0 +1,154 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeColumnType(t *testing.T) {
+	cases := []struct {
+		d        DriverType
+		expected string
+		actual   string
+	}{
+		{DriverTypePostgres, "BIGINT", "int8"},
+		{DriverTypePostgres, "TIMESTAMP WITH TIME ZONE", "timestamptz"},
+		{DriverTypePostgres, "NUMERIC(20)", "numeric"},
+		{DriverTypePostgres, "VARCHAR(50)", "varchar(50)"},
+		{DriverTypeMysql, "BIGINT UNSIGNED", "bigint(20) unsigned"},
+		{DriverTypeMysql, "BOOLEAN", "tinyint(1)"},
+		{DriverTypeMysql, "TINYINT", "tinyint(4)"},
+		{DriverTypeSqlite3, "INTEGER", "integer"},
+	}
+	for _, c := range cases {
+		if a, b := normalizeColumnType(c.d, c.expected), normalizeColumnType(c.d, c.actual); a != b {
+			t.Errorf("`%s` != `%s`", a, b)
+		}
+	}
+	if normalizeColumnType(DriverTypePostgres, "TEXT") == normalizeColumnType(DriverTypePostgres, "varchar(50)") {
+		t.Errorf("different types are equal")
+	}
+}
+
+func TestTableDiff_AlterStatements(t *testing.T) {
+	op := NewOperator(&testAccount{})
+	td := &TableDiff{
+		Table:          "account",
+		MissingColumns: []string{"score"},
+		ExtraColumns:   []string{"legacy"},
+		TypeMismatches: []ColumnTypeDiff{{Column: "name", Expected: "VARCHAR(50)", Actual: "text"}},
+		op:             op,
+		driverType:     DriverTypePostgres,
+	}
+	if s := td.String(); s != "table `account`: missing columns `score`; extra columns `legacy`; column `name` is `text`, expected `VARCHAR(50)`" {
+		t.Errorf("unexpected report: %s", s)
+	}
+
+	lst, err := td.AlterStatements(false)
+	expected := []string{
+		"ALTER TABLE account ADD COLUMN score DOUBLE PRECISION DEFAULT 0",
+		"ALTER TABLE account ALTER COLUMN name TYPE VARCHAR(50)",
+	}
+	if err != nil || !reflect.DeepEqual(lst, expected) {
+		t.Errorf("unexpected statements: %v %v", lst, err)
+	}
+
+	td.driverType = DriverTypeSqlite3
+	lst, err = td.AlterStatements(true)
+	expected = []string{
+		"ALTER TABLE account ADD COLUMN score REAL DEFAULT 0",
+		"ALTER TABLE account DROP COLUMN legacy",
+	}
+	if err != nil || !reflect.DeepEqual(lst, expected) {
+		t.Errorf("unexpected statements: %v %v", lst, err)
+	}
+
+	// MODIFY of a unique column must not add another index
+	td.driverType = DriverTypeMysql
+	td.MissingColumns, td.ExtraColumns = nil, nil
+	td.TypeMismatches = []ColumnTypeDiff{
+		{Column: "email", Expected: "VARCHAR(255)", Actual: "varchar(100)"},
+		{Column: "id", Expected: "BIGINT", Actual: "int"},
+		{Column: "score", Expected: "DOUBLE", Actual: "float"},
+	}
+	lst, err = td.AlterStatements(false)
+	expected = []string{
+		"ALTER TABLE account MODIFY COLUMN email VARCHAR(255) NOT NULL",
+		"ALTER TABLE account MODIFY COLUMN id BIGINT AUTO_INCREMENT",
+		"ALTER TABLE account MODIFY COLUMN score DOUBLE DEFAULT 0",
+	}
+	if err != nil || !reflect.DeepEqual(lst, expected) {
+		t.Errorf("unexpected statements: %v %v", lst, err)
+	}
+}
+
+type testDrift struct {
+	ID    int64          `db:"id,pk,autoincrement"`
+	Name  string         `db:"name,notnull"`
+	Nick  sql.NullString `db:"nick"`
+	Age   int            `db:"age,notnull,default=0"`
+	Score float64        `db:"score"`
+}
+
+func (d *testDrift) TableName() string { return "drift" }
+
+func (d *testDrift) TableColumns() []string { return nil }
+
+func TestDiffSchema_Sqlite(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	_, err := db.Execute(ctx, "CREATE TABLE drift (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, nick TEXT, score TEXT, legacy TEXT)", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drift, missing := NewOperator(&testDrift{}), NewOperator(&testAutoIncrement{})
+	diffs, err := DiffSchema(ctx, db, drift, missing, NewOperator(&testKV{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("unexpected diffs: %v", diffs)
+	}
+	td := diffs[0]
+	if td.Table != "drift" || td.MissingTable ||
+		!reflect.DeepEqual(td.MissingColumns, []string{"age"}) ||
+		!reflect.DeepEqual(td.ExtraColumns, []string{"legacy"}) ||
+		!reflect.DeepEqual(td.TypeMismatches, []ColumnTypeDiff{{Column: "score", Expected: "REAL", Actual: "TEXT"}}) {
+		t.Errorf("unexpected diff: %s", td)
+	}
+	if !diffs[1].MissingTable || diffs[1].Table != "auto_increment" {
+		t.Errorf("unexpected diff: %s", diffs[1])
+	}
+
+	for _, td := range diffs {
+		lst, err := td.AlterStatements(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, q := range lst {
+			if _, err = db.Execute(ctx, q, nil); err != nil {
+				t.Fatalf("%s: %v", q, err)
+			}
+		}
+	}
+	diffs, err = DiffSchema(ctx, db, drift, missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sqlite can not alter the type of a column
+	if len(diffs) != 1 || len(diffs[0].MissingColumns) > 0 || len(diffs[0].ExtraColumns) > 0 || len(diffs[0].TypeMismatches) != 1 {
+		t.Errorf("unexpected diffs: %v", diffs)
+	}
+}
+
+type testKV struct {
+	K string `db:"k,pk"`
+	V int64  `db:"v"`
+}
+
+func (kv *testKV) TableName() string { return "kv" }
+
+func (kv *testKV) TableColumns() []string { return nil }
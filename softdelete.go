@@ -0,0 +1,49 @@
+package sqlx
+
+import (
+	"context"
+	"strings"
+)
+
+const softDeleteParam = "__deleted_at"
+
+// Unscoped makes Operator include the soft deleted rows.
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, _KeyUnscoped, true)
+}
+
+// SoftDeleteColumn returns the column tagged `softdelete`, which should be a nullable timestamp.
+func (op *Operator) SoftDeleteColumn() string { return op.softDelete }
+
+// scope excludes the soft deleted rows from the condition, unless the context is Unscoped.
+func (op *Operator) scope(ctx context.Context, condition string) string {
+	if len(op.softDelete) < 1 || len(condition) < 1 || ctx.Value(_KeyUnscoped) != nil {
+		return condition
+	}
+	return "(" + condition + ") AND " + op.softDelete + " IS NULL"
+}
+
+func (op *Operator) SqlSoftDelete(condition string) string {
+	if len(condition) < 1 {
+		panic(ErrEmptyCondition)
+	}
+
+	var buf strings.Builder
+	buf.WriteString("UPDATE ")
+	buf.WriteString(op.model.TableName())
+	buf.WriteString(" SET ")
+	buf.WriteString(op.softDelete)
+	buf.WriteString("=${" + softDeleteParam + "} WHERE ")
+	buf.WriteString(condition)
+	return buf.String()
+}
+
+func (op *Operator) softDeleteRows(ctx context.Context, condition string, params interface{}) (int64, error) {
+	q := op.SqlSoftDelete(op.scope(ctx, condition))
+	ctx, exe := PickExecutor(ctx)
+	r, e := exe.Execute(ctx, q, Merge(Params{softDeleteParam: op.timestampValue(op.softDelete, NowFunc())}, params))
+	if e != nil {
+		return 0, e
+	}
+	return r.RowsAffected()
+}
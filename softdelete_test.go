@@ -0,0 +1,71 @@
+package sqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testPost struct {
+	ID        int64      `db:"id"`
+	Title     string     `db:"title"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+func (p *testPost) TableName() string { return "post" }
+
+func (p *testPost) TableColumns() []string { return nil }
+
+func TestOperator_SoftDelete(t *testing.T) {
+	op := NewOperator(&testPost{})
+	ctx := context.Background()
+	if op.SoftDeleteColumn() != "deleted_at" {
+		t.Errorf("unexpected soft delete column: %s", op.SoftDeleteColumn())
+	}
+	if q := op.scope(ctx, "id=${id}"); q != "(id=${id}) AND deleted_at IS NULL" {
+		t.Errorf("unexpected condition: %s", q)
+	}
+	if q := op.scope(Unscoped(ctx), "id=${id}"); q != "id=${id}" {
+		t.Errorf("unexpected condition: %s", q)
+	}
+	if q := op.SqlSoftDelete(op.scope(ctx, "id=${id}")); q != "UPDATE post SET deleted_at=${__deleted_at} WHERE (id=${id}) AND deleted_at IS NULL" {
+		t.Errorf("unexpected query: %s", q)
+	}
+	if q := NewOperator(&testUser{}).scope(ctx, "id=${id}"); q != "id=${id}" {
+		t.Errorf("unexpected condition: %s", q)
+	}
+}
+
+type testUnixPost struct {
+	ID        int64  `db:"id"`
+	DeletedAt *int64 `db:"deleted_at,softdelete"`
+}
+
+func (p *testUnixPost) TableName() string { return "post" }
+
+func (p *testUnixPost) TableColumns() []string { return nil }
+
+func TestOperator_SoftDeleteUnix(t *testing.T) {
+	db := openTestSqlite(t)
+	useTestSqlite(t, db)
+	ctx := context.Background()
+	if _, err := db.Execute(ctx, "CREATE TABLE post (id INTEGER PRIMARY KEY, deleted_at INTEGER)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Execute(ctx, "INSERT INTO post (id) VALUES (1)", nil); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	prev := NowFunc
+	NowFunc = func() time.Time { return now }
+	defer func() { NowFunc = prev }()
+
+	op := NewOperator(&testUnixPost{})
+	if n, err := op.Delete(ctx, "id=${id}", Params{"id": 1}); err != nil || n != 1 {
+		t.Fatalf("unexpected delete result: %d %v", n, err)
+	}
+	var post testUnixPost
+	if err := db.Get(ctx, "SELECT * FROM post WHERE id=1", nil, &post); err != nil || post.DeletedAt == nil || *post.DeletedAt != now.Unix() {
+		t.Errorf("unexpected post: %+v %v", post, err)
+	}
+}
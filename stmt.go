@@ -1,43 +1,136 @@
 package sqlx
 
 import (
+	"container/list"
 	"context"
 	"database/sql"
+	"sync"
 )
 
+// StmtExpandedCacheSize is the max count of statements prepared for the shapes of slice params, kept by each Stmt.
+// The least recently used one is closed if there are more.
+var StmtExpandedCacheSize = 16
+
+type _ExpandedStmt struct {
+	sign    string
+	std     *sql.Stmt
+	refs    int // count of running calls
+	evicted bool
+}
+
 type Stmt struct {
 	std    *sql.Stmt
-	keys   []string
+	tpl    *_Template
 	logger Logger
+
+	// slice params change the count of placeholders, so each shape needs its own prepared statement.
+	prepare  func(ctx context.Context, query string) (*sql.Stmt, error)
+	lock     sync.Mutex
+	lru      *list.List
+	expanded map[string]*list.Element
 }
 
 func (stmt *Stmt) Close() error {
 	if stmt.logger != nil {
 		stmt.logger.Printf("stmt close, sql.Stmt(%p)", stmt.std)
 	}
+	stmt.lock.Lock()
+	for _, ele := range stmt.expanded {
+		_ = ele.Value.(*_ExpandedStmt).std.Close()
+	}
+	stmt.expanded = nil
+	stmt.lru = nil
+	stmt.lock.Unlock()
 	return stmt.std.Close()
 }
 
+func noRelease() {}
+
+// bind returns the prepared statement of the shape of params, release must be called after the statement is used.
+func (stmt *Stmt) bind(ctx context.Context, params interface{}) (*sql.Stmt, []interface{}, func(), error) {
+	args, sizes, err := stmt.tpl.args(params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if sizes == nil {
+		return stmt.std, args, noRelease, nil
+	}
+
+	sign := sizesSignature(sizes)
+	stmt.lock.Lock()
+	defer stmt.lock.Unlock()
+	var item *_ExpandedStmt
+	if ele := stmt.expanded[sign]; ele != nil {
+		stmt.lru.MoveToFront(ele)
+		item = ele.Value.(*_ExpandedStmt)
+	} else {
+		query := stmt.tpl.render(sizes)
+		std, err := stmt.prepare(ctx, query)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if stmt.logger != nil {
+			stmt.logger.Printf("stmt prepared for slice params: %s, sql.Stmt(%p)", query, std)
+		}
+		if stmt.expanded == nil {
+			stmt.expanded = map[string]*list.Element{}
+			stmt.lru = list.New()
+		}
+		item = &_ExpandedStmt{sign: sign, std: std}
+		stmt.expanded[sign] = stmt.lru.PushFront(item)
+		for stmt.lru.Len() > StmtExpandedCacheSize && stmt.lru.Len() > 1 {
+			stmt.evict(stmt.lru.Back())
+		}
+	}
+	item.refs++
+	return item.std, args, func() { stmt.release(item) }, nil
+}
+
+// evict removes the statement from the cache, it is closed once no call is using it.
+func (stmt *Stmt) evict(ele *list.Element) {
+	item := stmt.lru.Remove(ele).(*_ExpandedStmt)
+	delete(stmt.expanded, item.sign)
+	item.evicted = true
+	if item.refs < 1 {
+		if stmt.logger != nil {
+			stmt.logger.Printf("stmt for slice params evicted, sql.Stmt(%p)", item.std)
+		}
+		_ = item.std.Close()
+	}
+}
+
+func (stmt *Stmt) release(item *_ExpandedStmt) {
+	stmt.lock.Lock()
+	defer stmt.lock.Unlock()
+	item.refs--
+	if item.evicted && item.refs < 1 {
+		_ = item.std.Close()
+	}
+}
+
 func (stmt *Stmt) Execute(ctx context.Context, params interface{}) (sql.Result, error) {
-	args, err := ParamsToArgs(params, stmt.keys)
+	std, args, release, err := stmt.bind(ctx, params)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 	if stmt.logger != nil {
-		stmt.logger.Printf("stmt execute, args(%v), sql.Stmt(%p)", args, stmt.std)
+		stmt.logger.Printf("stmt execute, args(%v), sql.Stmt(%p)", args, std)
 	}
-	return stmt.std.ExecContext(ctx, args...)
+	return std.ExecContext(ctx, args...)
 }
 
 func (stmt *Stmt) Rows(ctx context.Context, params interface{}) (*Rows, error) {
-	args, err := ParamsToArgs(params, stmt.keys)
+	std, args, release, err := stmt.bind(ctx, params)
 	if err != nil {
 		return nil, err
 	}
+	// database/sql keeps a closed statement alive until its rows are closed, so it can be released after the query
+	defer release()
 	if stmt.logger != nil {
-		stmt.logger.Printf("stmt select, args(%v), sql.Stmt(%p)", args, stmt.std)
+		stmt.logger.Printf("stmt select, args(%v), sql.Stmt(%p)", args, std)
 	}
-	rows, err := stmt.std.QueryContext(ctx, args...)
+	rows, err := std.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +142,7 @@ func (stmt *Stmt) Get(ctx context.Context, params interface{}, dist interface{})
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	return rows.get(dist)
 }
 
@@ -57,6 +151,7 @@ func (stmt *Stmt) Select(ctx context.Context, params interface{}, dist interface
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	return rows._select(dist)
 }
 
@@ -69,6 +164,7 @@ func (stmt *Stmt) GetJoined(ctx context.Context, params interface{}, dist interf
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	return rows.getJoined(dist, joinedGet)
 }
 
@@ -77,5 +173,6 @@ func (stmt *Stmt) SelectJoined(ctx context.Context, params interface{}, ptrOfJoi
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	return rows.selectJoined(ptrOfJoinedDistSlice, joinedGet)
 }
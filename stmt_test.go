@@ -0,0 +1,43 @@
+package sqlx
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestStmt_ExpandedCacheSize(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+	var keys []string
+	for i := 0; i < 8; i++ {
+		k := strconv.Itoa(i)
+		insertKV(t, db, k)
+		keys = append(keys, k)
+	}
+
+	prev := StmtExpandedCacheSize
+	StmtExpandedCacheSize = 3
+	t.Cleanup(func() { StmtExpandedCacheSize = prev })
+
+	stmt, err := db.Prepare(ctx, "SELECT COUNT(*) FROM kv WHERE k IN (${keys})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	for round := 0; round < 2; round++ {
+		for i := 1; i <= len(keys); i++ {
+			var n int64
+			if err = stmt.Get(ctx, Params{"keys": keys[:i]}, &n); err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(i) {
+				t.Errorf("size %d: got %d", i, n)
+			}
+			if len(stmt.expanded) > StmtExpandedCacheSize || stmt.lru.Len() != len(stmt.expanded) {
+				t.Errorf("size %d: %d statements cached", i, len(stmt.expanded))
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package sqlx
+
+import (
+	"reflect"
+	"time"
+)
+
+// NowFunc is the time source of Operator, for soft delete and auto timestamps.
+var NowFunc = time.Now
+
+// timestampValue returns now as the type of the column, unix seconds for integers, including their pointers and
+// sql.Null types, otherwise time.Time.
+func (op *Operator) timestampValue(column string, now time.Time) interface{} {
+	f := op.smap.Names[column]
+	if f != nil {
+		t := f.Field.Type
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if nt, ok := nullTypes[t]; ok {
+			t = nt
+		}
+		switch t.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			return now.Unix()
+		}
+	}
+	return now
+}
+
+func isZeroValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	return reflect.ValueOf(v).IsZero()
+}
+
+// fillTimestamps returns a copy of the data, in which zero or missing `autoCreateTime` and `autoUpdateTime` columns
+// are set in INSERT, and `autoUpdateTime` columns are always refreshed in UPDATE.
+// Zero `autoCreateTime` columns are removed in UPDATE, so an update from a struct does not clear them.
+func (op *Operator) fillTimestamps(data Params, insert bool) Params {
+	if len(op.autoCreates) < 1 && len(op.autoUpdates) < 1 {
+		return data
+	}
+
+	m := make(Params, len(data)+len(op.autoCreates)+len(op.autoUpdates))
+	for k, v := range data {
+		m[k] = v
+	}
+	now := NowFunc()
+	for _, c := range op.autoCreates {
+		if insert {
+			if isZeroValue(m[c]) {
+				m[c] = op.timestampValue(c, now)
+			}
+		} else if v, ok := m[c]; ok && isZeroValue(v) {
+			delete(m, c)
+		}
+	}
+	for _, c := range op.autoUpdates {
+		if !insert || isZeroValue(m[c]) {
+			m[c] = op.timestampValue(c, now)
+		}
+	}
+	return m
+}
@@ -0,0 +1,49 @@
+package sqlx
+
+import (
+	"testing"
+	"time"
+)
+
+type testArticle struct {
+	ID        int64     `db:"id"`
+	Title     string    `db:"title"`
+	CreatedAt time.Time `db:"created_at,autoCreateTime"`
+	UpdatedAt int64     `db:"updated_at,autoUpdateTime"`
+}
+
+func (a *testArticle) TableName() string { return "article" }
+
+func (a *testArticle) TableColumns() []string { return nil }
+
+func TestOperator_FillTimestamps(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	NowFunc = func() time.Time { return now }
+	defer func() { NowFunc = time.Now }()
+
+	op := NewOperator(&testArticle{})
+	data := Params{"title": "a"}
+	m := op.fillTimestamps(data, true)
+	if len(data) != 1 || m["created_at"] != now || m["updated_at"] != now.Unix() {
+		t.Errorf("unexpected insert data: %v", m)
+	}
+
+	created := now.Add(-time.Hour)
+	m = op.fillTimestamps(Params{"title": "a", "created_at": created}, true)
+	if m["created_at"] != created {
+		t.Errorf("unexpected insert data: %v", m)
+	}
+
+	dm, err := writeData(&testArticle{Title: "b", UpdatedAt: 1}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = op.fillTimestamps(dm, false)
+	if _, ok := m["created_at"]; ok || m["updated_at"] != now.Unix() {
+		t.Errorf("unexpected update data: %v", m)
+	}
+	m = op.fillTimestamps(Params{"title": "b"}, false)
+	if len(m) != 2 || m["updated_at"] != now.Unix() {
+		t.Errorf("unexpected update data: %v", m)
+	}
+}
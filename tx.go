@@ -3,6 +3,7 @@ package sqlx
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 )
 
@@ -12,12 +13,19 @@ type Tx struct {
 	savepoint string
 	ctx       context.Context
 	readonly  bool
+	parent    *Tx
+	depth     int
+	children  int // count of the unfinished nested txs
+	seq       int // of the auto-generated savepoint names, only used by the outermost tx
+	done      bool
 }
 
 func (tx *Tx) Raw() *sql.Tx { return tx.std }
 
 func (tx *Tx) Database() *DB { return tx.db }
 
+func (tx *Tx) DriverType() DriverType { return tx.db.driverType }
+
 func (tx *Tx) BindParams(query string, params interface{}) (string, []interface{}, error) {
 	return tx.db.BindParams(query, params)
 }
@@ -63,7 +71,8 @@ func (tx *Tx) SelectJoined(ctx context.Context, query string, params interface{}
 }
 
 func (tx *Tx) Prepare(ctx context.Context, query string) (*Stmt, error) {
-	query, keys := BindParams(tx.db.driverType, query)
+	tpl := tx.db.template(query)
+	query = tpl.query
 	stmt, err := tx.std.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -72,9 +81,10 @@ func (tx *Tx) Prepare(ctx context.Context, query string) (*Stmt, error) {
 		tx.db.logger.Printf("stmt prepared by tx: %s, sql.Stmt(%p), sql.Tx(%p)", query, stmt, tx.std)
 	}
 	return &Stmt{
-		std:    stmt,
-		keys:   keys,
-		logger: tx.db.logger,
+		std:     stmt,
+		tpl:     tpl,
+		prepare: tx.std.PrepareContext,
+		logger:  tx.db.logger,
 	}, nil
 }
 
@@ -83,24 +93,61 @@ func (tx *Tx) Stmt(stmt *Stmt) *Stmt {
 	if tx.db.logger != nil {
 		tx.db.logger.Printf("tx wrap stmt: (%p)=>(%p), sql.Tx(%p)", stmt, v, tx.std)
 	}
-	return &Stmt{std: v, keys: stmt.keys, logger: stmt.logger}
+	return &Stmt{
+		std:     v,
+		tpl:     stmt.tpl,
+		prepare: tx.std.PrepareContext,
+		logger:  stmt.logger,
+	}
 }
 
 var _ Executor = (*Tx)(nil)
 
+var ErrUnfinishedNestedTx = errors.New("sqlx: nested tx is not finished")
+
+// Depth returns 0 for a tx of the DB, and the nesting depth for a savepoint tx.
+func (tx *Tx) Depth() int { return tx.depth }
+
+// Savepoint returns the savepoint name of the nested tx, or empty.
+func (tx *Tx) Savepoint() string { return tx.savepoint }
+
+// active returns sql.ErrTxDone if the tx or one of its parents is finished.
+func (tx *Tx) active() error {
+	for t := tx; t != nil; t = t.parent {
+		if t.done {
+			return sql.ErrTxDone
+		}
+	}
+	return nil
+}
+
+func (tx *Tx) quotedSavepoint() string { return tx.DriverType().QuoteIdentifier(tx.savepoint) }
+
+// BeginTx begins a nested tx via a savepoint, a name like `sqlx_sp_1` is generated if the savepoint is empty.
+// The nested tx keeps the readonly of the tx.
 func (tx *Tx) BeginTx(ctx context.Context, savepoint string) (*Tx, error) {
-	if tx.readonly {
-		return nil, ErrReadonly
+	if err := tx.active(); err != nil {
+		return nil, err
+	}
+	if len(savepoint) < 1 {
+		root := tx
+		for root.parent != nil {
+			root = root.parent
+		}
+		root.seq++
+		savepoint = fmt.Sprintf("sqlx_sp_%d", root.seq)
 	}
 	if tx.db.logger != nil {
 		tx.db.logger.Printf("tx begin via savepoint, `%s`, sql.Tx(%p);", savepoint, tx.std)
 	}
 
-	_, err := tx.Execute(ctx, fmt.Sprintf("SAVEPOINT %s_BEGIN", savepoint), nil)
+	ntx := &Tx{std: tx.std, db: tx.db, savepoint: savepoint, ctx: ctx, readonly: tx.readonly, parent: tx, depth: tx.depth + 1}
+	_, err := tx.Execute(ctx, "SAVEPOINT "+ntx.quotedSavepoint(), nil)
 	if err != nil {
 		return nil, err
 	}
-	return &Tx{std: tx.std, db: tx.db, savepoint: savepoint, ctx: ctx}, nil
+	tx.children++
+	return ntx, nil
 }
 
 func (tx *Tx) MustBeginTx(ctx context.Context, savepoint string) *Tx {
@@ -111,40 +158,67 @@ func (tx *Tx) MustBeginTx(ctx context.Context, savepoint string) *Tx {
 	return t
 }
 
+func (tx *Tx) finish() {
+	tx.done = true
+	if tx.parent != nil {
+		tx.parent.children--
+	}
+}
+
+// Commit commits the tx, or releases the savepoint of a nested tx. The nested txs must be finished before.
 func (tx *Tx) Commit() error {
-	if len(tx.savepoint) < 1 {
+	if err := tx.active(); err != nil {
+		return err
+	}
+	if tx.children > 0 {
+		return ErrUnfinishedNestedTx
+	}
+	if tx.parent == nil {
 		if tx.db.logger != nil {
 			tx.db.logger.Printf("tx commit, sql.Tx(%p);", tx.std)
 		}
+		tx.done = true
 		return tx.std.Commit()
 	}
 	if tx.db.logger != nil {
 		tx.db.logger.Printf("tx commit via savepoint, `%s`, sql.Tx(%p);", tx.savepoint, tx.std)
 	}
-	_, err := tx.Execute(tx.ctx, fmt.Sprintf("SAVEPOINT %s", tx.savepoint), nil)
-	if err != nil {
+	if _, err := tx.Execute(tx.ctx, "RELEASE SAVEPOINT "+tx.quotedSavepoint(), nil); err != nil {
 		return err
 	}
-	_, err = tx.Execute(tx.ctx, fmt.Sprintf("RELASE SAVEPOINT %s_BEGIN", tx.savepoint), nil)
-	return err
+	tx.finish()
+	return nil
 }
 
+// Rollback rolls back the tx, or rolls back to the savepoint of a nested tx and releases it.
+// The unfinished nested txs are rolled back too.
 func (tx *Tx) Rollback() error {
-	if len(tx.savepoint) < 1 {
+	if err := tx.active(); err != nil {
+		return err
+	}
+	if tx.parent == nil {
 		if tx.db.logger != nil {
 			tx.db.logger.Printf("tx rollback, sql.Tx(%p);", tx.std)
 		}
+		tx.done = true
 		return tx.std.Rollback()
 	}
 	if tx.db.logger != nil {
 		tx.db.logger.Printf("tx rollback via savepoint, `%s`, sql.Tx(%p);", tx.savepoint, tx.std)
 	}
-	_, err := tx.Execute(tx.ctx, fmt.Sprintf("ROLLBACK TO SAVEPOINT %s_BEGIN", tx.savepoint), nil)
-	return err
+	name := tx.quotedSavepoint()
+	if _, err := tx.Execute(tx.ctx, "ROLLBACK TO SAVEPOINT "+name, nil); err != nil {
+		return err
+	}
+	if _, err := tx.Execute(tx.ctx, "RELEASE SAVEPOINT "+name, nil); err != nil {
+		return err
+	}
+	tx.finish()
+	return nil
 }
 
 func (tx *Tx) RollbackTo(savepoint string) error {
-	_, err := tx.Execute(tx.ctx, fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", savepoint), nil)
+	_, err := tx.Execute(tx.ctx, "ROLLBACK TO SAVEPOINT "+tx.DriverType().QuoteIdentifier(savepoint), nil)
 	return err
 }
 
@@ -0,0 +1,162 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestSqlite(t *testing.T) *DB {
+	db, err := Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Raw().SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Raw().Close() })
+	if _, err = db.Execute(context.Background(), "CREATE TABLE kv (k TEXT PRIMARY KEY, v INTEGER)", nil); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// useTestSqlite makes db the writeable DB of the operators during the test.
+func useTestSqlite(t *testing.T, db *DB) {
+	prev := wDB
+	wDB = db
+	t.Cleanup(func() { wDB = prev })
+}
+
+func countKV(t *testing.T, exe Executor) int64 {
+	var n int64
+	if err := exe.Get(context.Background(), "SELECT COUNT(*) FROM kv", nil, &n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func insertKV(t *testing.T, exe Executor, k string) {
+	if _, err := exe.Execute(context.Background(), "INSERT INTO kv (k, v) VALUES (${k}, 1)", Params{"k": k}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTx_Savepoint(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+
+	tx := db.MustBeginTx(ctx, nil)
+	insertKV(t, tx, "a")
+
+	committed := tx.MustBeginTx(ctx, "")
+	insertKV(t, committed, "b")
+	if committed.Depth() != 1 || committed.Savepoint() != "sqlx_sp_1" {
+		t.Errorf("unexpected nested tx: %d %s", committed.Depth(), committed.Savepoint())
+	}
+	if err := committed.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	rolledBack := tx.MustBeginTx(ctx, `odd "name"; --`)
+	insertKV(t, rolledBack, "c")
+	inner := rolledBack.MustBeginTx(ctx, "")
+	if inner.Depth() != 2 || inner.Savepoint() != "sqlx_sp_2" {
+		t.Errorf("unexpected nested tx: %d %s", inner.Depth(), inner.Savepoint())
+	}
+	insertKV(t, inner, "d")
+	if err := rolledBack.Commit(); !errors.Is(err, ErrUnfinishedNestedTx) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := rolledBack.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Commit(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := rolledBack.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n := countKV(t, tx); n != 2 {
+		t.Errorf("unexpected count: %d", n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countKV(t, db); n != 2 {
+		t.Errorf("unexpected count: %d", n)
+	}
+}
+
+func TestTx_UnfinishedNestedTx(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+
+	tx := db.MustBeginTx(ctx, nil)
+	ntx := tx.MustBeginTx(ctx, "")
+	insertKV(t, ntx, "a")
+	if err := tx.Commit(); !errors.Is(err, ErrUnfinishedNestedTx) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ntx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countKV(t, db); n != 1 {
+		t.Errorf("unexpected count: %d", n)
+	}
+
+	tx = db.MustBeginTx(ctx, nil)
+	ntx = tx.MustBeginTx(ctx, "")
+	insertKV(t, ntx, "b")
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ntx.Commit(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n := countKV(t, db); n != 1 {
+		t.Errorf("unexpected count: %d", n)
+	}
+}
+
+func TestTx_SavepointReadonly(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	ntx := tx.MustBeginTx(ctx, "")
+	if !ntx.readonly {
+		t.Errorf("nested tx is not readonly")
+	}
+	if err = ntx.Commit(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTx_RollbackTo(t *testing.T) {
+	db := openTestSqlite(t)
+	ctx := context.Background()
+
+	tx := db.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+	insertKV(t, tx, "a")
+	if _, err := tx.Execute(ctx, "SAVEPOINT "+tx.DriverType().QuoteIdentifier("mark"), nil); err != nil {
+		t.Fatal(err)
+	}
+	insertKV(t, tx, "b")
+	if err := tx.RollbackTo("mark"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countKV(t, tx); n != 1 {
+		t.Errorf("unexpected count: %d", n)
+	}
+}
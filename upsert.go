@@ -0,0 +1,201 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrReturningUnsupported = errors.New("sqlx: returning is not supported by the driver")
+
+// OnConflict is the conflict handling of Operator.Upsert.
+type OnConflict struct {
+	// Columns is the conflict target, required by postgres and sqlite. mysql uses all unique keys.
+	Columns []string
+	// Update is the columns updated on conflict, nil means all inserted columns
+	// except the conflict target, the immutable ones and the `autoCreateTime` ones.
+	Update []string
+	// DoNothing keeps the existing row on conflict.
+	DoNothing bool
+}
+
+func (op *Operator) conflictUpdateColumns(columns []string, conflict *OnConflict) []string {
+	if conflict.DoNothing {
+		return nil
+	}
+	if conflict.Update != nil {
+		return conflict.Update
+	}
+	targets := map[string]bool{}
+	for _, c := range conflict.Columns {
+		targets[c] = true
+	}
+	for _, c := range op.autoCreates {
+		targets[c] = true
+	}
+	var lst []string
+	for _, c := range columns {
+		if targets[c] || op.immutables[c] {
+			continue
+		}
+		lst = append(lst, c)
+	}
+	return lst
+}
+
+// SqlUpsert renders `INSERT ... ON CONFLICT` for postgres and sqlite, and `INSERT ... ON DUPLICATE KEY UPDATE` for mysql.
+func (op *Operator) SqlUpsert(d DriverType, columns []string, conflict *OnConflict, returning *Returning) (string, error) {
+	if conflict == nil {
+		conflict = &OnConflict{}
+	}
+	for _, c := range append(append([]string{}, conflict.Columns...), conflict.Update...) {
+		if _, ok := op.smap.Names[c]; !ok {
+			return "", fmt.Errorf("sqlx: unknown column `%s`", c)
+		}
+	}
+	if d == DriverTypeMysql && returning != nil {
+		return "", ErrReturningUnsupported
+	}
+
+	insert := op.SqlInsert(columns, nil)
+	updates := op.conflictUpdateColumns(columns, conflict)
+
+	var buf strings.Builder
+	buf.WriteString(insert)
+	switch d {
+	case DriverTypeMysql:
+		buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(updates) < 1 { // a no-op update, unlike `INSERT IGNORE`, which ignores other errors too
+			c := columns[0]
+			if len(conflict.Columns) > 0 {
+				c = conflict.Columns[0]
+			}
+			buf.WriteString(c)
+			buf.WriteByte('=')
+			buf.WriteString(c)
+			break
+		}
+		for i, c := range updates {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(fmt.Sprintf("%s=VALUES(%s)", c, c))
+		}
+	case DriverTypePostgres, DriverTypeSqlite3:
+		if len(conflict.Columns) < 1 {
+			return "", fmt.Errorf("sqlx: conflict columns are required by the driver")
+		}
+		buf.WriteString(" ON CONFLICT (")
+		buf.WriteString(strings.Join(conflict.Columns, ","))
+		buf.WriteString(") DO ")
+		if len(updates) < 1 {
+			buf.WriteString("NOTHING")
+			break
+		}
+		buf.WriteString("UPDATE SET ")
+		for i, c := range updates {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(fmt.Sprintf("%s=EXCLUDED.%s", c, c))
+		}
+	default:
+		return "", fmt.Errorf("sqlx: unsupported driver")
+	}
+
+	if returning != nil {
+		buf.WriteString(" RETURNING ")
+		buf.WriteString(strings.Join(returning.Keys, ","))
+	}
+	return buf.String(), nil
+}
+
+// Upsert inserts a row, or updates the conflicting row. With DoNothing, nothing is returned if the row is conflicting.
+// If the driver does not support RETURNING, the row is selected by the conflict columns, or the primary key
+// if there is no conflict column, in the same transaction. Insert hooks of the model are called.
+func (op *Operator) Upsert(ctx context.Context, params interface{}, conflict *OnConflict, returning *Returning) (int64, error) {
+	var n int64
+	err := op.withHooks(ctx, params, hookBeforeInsert, hookAfterInsert, func(ctx context.Context) error {
+		var err error
+		n, err = op.upsert(ctx, params, conflict, returning)
+		return err
+	})
+	return n, err
+}
+
+func (op *Operator) upsert(ctx context.Context, params interface{}, conflict *OnConflict, returning *Returning) (int64, error) {
+	pm, err := writeData(params, true)
+	if err != nil {
+		return 0, err
+	}
+	pm = op.fillTimestamps(pm, true)
+	if len(pm) < 1 {
+		return 0, ErrEmptyData
+	}
+
+	ctx, exe := PickExecutor(ctx)
+	d := exe.DriverType()
+	if returning != nil && !d.SupportsReturning() {
+		return 0, op.upsertEmulated(ctx, exe, pm, conflict, returning)
+	}
+	q, err := op.SqlUpsert(d, pm.Keys(), conflict, returning)
+	if err != nil {
+		return 0, err
+	}
+	if returning == nil {
+		r, e := exe.Execute(ctx, q, pm)
+		if e != nil {
+			return 0, e
+		}
+		return r.RowsAffected()
+	}
+	_, err = op.scanReturning(ctx, exe, q, pm, returning)
+	return 0, err
+}
+
+// upsertEmulated upserts the row, then selects the returning keys by the conflict columns or the primary key.
+func (op *Operator) upsertEmulated(ctx context.Context, exe Executor, pm Params, conflict *OnConflict, returning *Returning) error {
+	if conflict == nil {
+		conflict = &OnConflict{}
+	}
+	keys := conflict.Columns
+	if len(keys) < 1 {
+		if _, ok := pm[op.pk]; !ok || len(op.pk) < 1 {
+			return fmt.Errorf("%w, conflict columns or the primary key are required to emulate it", ErrReturningUnsupported)
+		}
+		keys = []string{op.pk}
+	}
+	q, err := op.SqlUpsert(exe.DriverType(), pm.Keys(), conflict, nil)
+	if err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(" AND ")
+		}
+		buf.WriteString(k)
+		buf.WriteString("=${")
+		buf.WriteString(k)
+		buf.WriteByte('}')
+	}
+	return inTx(ctx, exe, func(ctx context.Context, exe Executor) error {
+		r, err := exe.Execute(ctx, q, pm)
+		if err != nil {
+			return err
+		}
+		if conflict.DoNothing {
+			n, err := r.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if n < 1 { // conflicting, nothing is returned like the native RETURNING
+				return nil
+			}
+		}
+		_, err = op.scanReturning(ctx, exe, op.SqlSelect("!"+strings.Join(returning.Keys, ","), buf.String()), pm, returning)
+		return err
+	})
+}
@@ -0,0 +1,119 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOperator_SqlUpsert(t *testing.T) {
+	op := NewOperator(&testUser{})
+	columns := []string{"age", "created_at", "name"}
+	conflict := &OnConflict{Columns: []string{"name"}}
+
+	cases := []struct {
+		d         DriverType
+		conflict  *OnConflict
+		returning *Returning
+		query     string
+	}{
+		{
+			DriverTypePostgres, conflict, &Returning{Keys: []string{"id"}},
+			"INSERT INTO user(age,created_at,name) VALUES(${age},${created_at},${name}) ON CONFLICT (name) DO UPDATE SET age=EXCLUDED.age RETURNING id",
+		},
+		{
+			DriverTypeSqlite3, &OnConflict{Columns: []string{"name"}, DoNothing: true}, nil,
+			"INSERT INTO user(age,created_at,name) VALUES(${age},${created_at},${name}) ON CONFLICT (name) DO NOTHING",
+		},
+		{
+			DriverTypeMysql, conflict, nil,
+			"INSERT INTO user(age,created_at,name) VALUES(${age},${created_at},${name}) ON DUPLICATE KEY UPDATE age=VALUES(age)",
+		},
+		{
+			DriverTypeMysql, &OnConflict{Columns: []string{"name"}, DoNothing: true}, nil,
+			"INSERT INTO user(age,created_at,name) VALUES(${age},${created_at},${name}) ON DUPLICATE KEY UPDATE name=name",
+		},
+		{
+			DriverTypeMysql, &OnConflict{Update: []string{"age", "nickname"}}, nil,
+			"INSERT INTO user(age,created_at,name) VALUES(${age},${created_at},${name}) ON DUPLICATE KEY UPDATE age=VALUES(age),nickname=VALUES(nickname)",
+		},
+	}
+	for _, c := range cases {
+		q, err := op.SqlUpsert(c.d, columns, c.conflict, c.returning)
+		if err != nil || q != c.query {
+			t.Errorf("unexpected query: %s %v", q, err)
+		}
+	}
+
+	if _, err := op.SqlUpsert(DriverTypeMysql, columns, conflict, &Returning{}); err != ErrReturningUnsupported {
+		t.Errorf("expected ErrReturningUnsupported, got %v", err)
+	}
+	if _, err := op.SqlUpsert(DriverTypePostgres, columns, nil, nil); err == nil {
+		t.Errorf("expected error of missing conflict columns")
+	}
+	if _, err := op.SqlUpsert(DriverTypePostgres, columns, &OnConflict{Columns: []string{"nmae"}}, nil); err == nil {
+		t.Errorf("expected unknown column error")
+	}
+}
+
+type testTag struct {
+	ID    int64  `db:"id,pk,autoincrement"`
+	Name  string `db:"name,notnull,unique"`
+	Count int64  `db:"count,notnull"`
+}
+
+func (g *testTag) TableName() string { return "tag" }
+
+func (g *testTag) TableColumns() []string { return nil }
+
+func (g *testTag) BeforeInsert(ctx context.Context, exe Executor) error {
+	g.Name = strings.ToLower(g.Name)
+	return nil
+}
+
+func TestOperator_UpsertReturning(t *testing.T) {
+	useTestSqlite(t, openTestSqlite(t))
+	ctx := context.Background()
+	op := NewOperator(&testTag{})
+	if err := op.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	conflict := &OnConflict{Columns: []string{"name"}}
+
+	for _, native := range []bool{true, false} {
+		prev := Sqlite3SupportsReturning
+		Sqlite3SupportsReturning = native
+
+		var ids []int64
+		returning := &Returning{Keys: []string{"id"}, Rows: &ids}
+		if _, err := op.Upsert(ctx, &testTag{Name: "Go", Count: 1}, conflict, returning); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := op.Upsert(ctx, &testTag{Name: "go", Count: 2}, conflict, returning); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := op.Upsert(ctx, &testTag{Name: "GO", Count: 3}, &OnConflict{Columns: []string{"name"}, DoNothing: true}, returning); err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 2 || ids[0] != ids[1] {
+			t.Errorf("unexpected returning of native(%v): %v", native, ids)
+		}
+
+		var count int64
+		if _, err := op.Upsert(ctx, &testTag{Name: "sql", Count: 5}, conflict, &Returning{Keys: []string{"count"}, Dists: DirectDists{&count}}); err != nil {
+			t.Fatal(err)
+		}
+		if count != 5 {
+			t.Errorf("unexpected returning of native(%v): %d", native, count)
+		}
+		if _, err := op.Upsert(ctx, &testTag{Name: "x"}, nil, &Returning{Keys: []string{"id"}, Rows: &ids}); !native && !errors.Is(err, ErrReturningUnsupported) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		Sqlite3SupportsReturning = prev
+		if _, err := op.HardDelete(ctx, "1=1", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
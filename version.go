@@ -0,0 +1,66 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// ErrStaleObject is returned by Update if the row was changed since the version of the data was loaded.
+var ErrStaleObject = errors.New("sqlx: stale object")
+
+// ErrMissingVersion is returned by Update if the model is versioned but the data does not carry the version,
+// which would update the rows without the optimistic locking.
+var ErrMissingVersion = errors.New("sqlx: missing version of versioned model")
+
+const oldVersionParam = "__old_version"
+
+// VersionColumn returns the column tagged `version`, or empty if the model has no optimistic locking.
+func (op *Operator) VersionColumn() string { return op.version }
+
+// Unversioned makes Update accept the data without the version of a versioned model,
+// the rows are updated without the optimistic locking, and their versions are not changed.
+func Unversioned(ctx context.Context) context.Context {
+	return context.WithValue(ctx, _KeyUnversioned, true)
+}
+
+// isVersioned reports whether the update columns carry the version, so the update is optimistic locked.
+func (op *Operator) isVersioned(columns []string) bool {
+	if len(op.version) < 1 {
+		return false
+	}
+	for _, c := range columns {
+		if c == op.version {
+			return true
+		}
+	}
+	return false
+}
+
+// versionCondition appends the old version check to the condition.
+func (op *Operator) versionCondition(condition string) string {
+	return "(" + condition + ") AND " + op.version + "=${" + oldVersionParam + "}"
+}
+
+// bumpVersion increments the version field of the data if it is a struct pointer, so it can be updated again.
+// The data may be another struct than the model, like a form, so the field is looked up by its own type.
+func (op *Operator) bumpVersion(data interface{}) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	f := mapper.TypeMap(v.Elem().Type()).Names[op.version]
+	if f == nil {
+		return
+	}
+	fv, ok := fieldByIndex(v.Elem(), f.Index)
+	if !ok || !fv.CanSet() {
+		return
+	}
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fv.SetInt(fv.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(fv.Uint() + 1)
+	}
+}
@@ -0,0 +1,97 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testDoc struct {
+	ID      int64  `db:"id"`
+	Title   string `db:"title"`
+	Version int64  `db:"version,version"`
+}
+
+func (d *testDoc) TableName() string { return "doc" }
+
+func (d *testDoc) TableColumns() []string { return nil }
+
+func TestOperator_Version(t *testing.T) {
+	op := NewOperator(&testDoc{})
+	if op.VersionColumn() != "version" {
+		t.Errorf("unexpected version column: %s", op.VersionColumn())
+	}
+	q := op.SqlUpdate("id=${id}", []string{"title", "version"}, nil)
+	if q != "UPDATE doc SET title=${title},version=version+1 WHERE (id=${id}) AND version=${__old_version}" {
+		t.Errorf("unexpected query: %s", q)
+	}
+	if q := op.SqlUpdate("id=${id}", []string{"title"}, nil); q != "UPDATE doc SET title=${title} WHERE id=${id}" {
+		t.Errorf("unexpected query: %s", q)
+	}
+
+	doc := &testDoc{Version: 3}
+	op.bumpVersion(doc)
+	if doc.Version != 4 {
+		t.Errorf("unexpected version: %d", doc.Version)
+	}
+	op.bumpVersion(*doc)
+	if doc.Version != 4 {
+		t.Errorf("unexpected version: %d", doc.Version)
+	}
+}
+
+func TestOperator_UpdateMissingVersion(t *testing.T) {
+	db := openTestSqlite(t)
+	useTestSqlite(t, db)
+	ctx := context.Background()
+	if _, err := db.Execute(ctx, "CREATE TABLE doc (id INTEGER PRIMARY KEY, title TEXT, version INTEGER)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Execute(ctx, "INSERT INTO doc (id, title, version) VALUES (1, 'a', 3)", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	op := NewOperator(&testDoc{})
+	if _, err := op.Update(ctx, "id=${id}", Params{"id": 1}, Params{"title": "b"}, nil); !errors.Is(err, ErrMissingVersion) {
+		t.Errorf("expected ErrMissingVersion, got %v", err)
+	}
+	n, err := op.Update(Unversioned(ctx), "id=${id}", Params{"id": 1}, Params{"title": "b"}, nil)
+	if err != nil || n != 1 {
+		t.Fatalf("unexpected update result: %d %v", n, err)
+	}
+	var doc testDoc
+	if err = db.Get(ctx, "SELECT * FROM doc WHERE id=1", nil, &doc); err != nil || doc.Title != "b" || doc.Version != 3 {
+		t.Errorf("unexpected doc: %+v %v", doc, err)
+	}
+
+	if _, err = op.Update(ctx, "id=${id}", Params{"id": 1}, &doc, nil); err != nil || doc.Version != 4 {
+		t.Errorf("unexpected versioned update: %+v %v", doc, err)
+	}
+}
+
+type testDocForm struct {
+	Version int64  `db:"version"`
+	Title   string `db:"title"`
+}
+
+func TestOperator_UpdateVersionByForm(t *testing.T) {
+	db := openTestSqlite(t)
+	useTestSqlite(t, db)
+	ctx := context.Background()
+	if _, err := db.Execute(ctx, "CREATE TABLE doc (id INTEGER PRIMARY KEY, title TEXT, version INTEGER)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Execute(ctx, "INSERT INTO doc (id, title, version) VALUES (1, 'a', 3)", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	op := NewOperator(&testDoc{})
+	form := &testDocForm{Version: 3, Title: "b"}
+	if _, err := op.Update(ctx, "id=${id}", Params{"id": 1}, form, nil); err != nil || form.Version != 4 || form.Title != "b" {
+		t.Errorf("unexpected form: %+v %v", form, err)
+	}
+	var doc testDoc
+	if err := db.Get(ctx, "SELECT * FROM doc WHERE id=1", nil, &doc); err != nil || doc.Title != "b" || doc.Version != 4 {
+		t.Errorf("unexpected doc: %+v %v", doc, err)
+	}
+}